@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+/*
+CoercingCanConvert is a more lenient version of DefaultCanConvert which also allows
+converting between strings and numbers, as long as no data is lost. This is useful
+for form posts, query strings, config files, etc. where every value is a string.
+
+In addition to the conversions allowed by DefaultCanConvert, the following are allowed
+(src -> dst):
+
+	"26"   -> int
+	"2.5"  -> float
+	"true" -> bool
+	"1h"   -> time.Duration
+	26     -> string
+
+Lossy conversions such as "2.5" -> int or "300" -> uint8 are still a type mismatch.
+Fields that were coerced are added to CoercedFields in the CompareResults.
+*/
+func CoercingCanConvert(t reflect.Type, v reflect.Value) bool {
+	if DefaultCanConvert(t, v) {
+		return true
+	} else if !v.IsValid() {
+		return false
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	_, ok := coerceValue(t, v)
+
+	return ok
+}
+
+// coerceValue converts the src value (v) to type t if it's a string that can be
+// parsed as t without any loss, or if it's a number and t is a string. Returns false
+// if v can't be coerced, or if coercing doesn't apply to t and v.
+func coerceValue(t reflect.Type, v reflect.Value) (reflect.Value, bool) {
+	out := reflect.New(t).Elem()
+
+	if v.Kind() == reflect.String {
+		s := v.String()
+
+		if t == durationType {
+			d, err := time.ParseDuration(s)
+
+			if err != nil {
+				return out, false
+			}
+
+			out.SetInt(int64(d))
+
+			return out, true
+		}
+
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(s, 10, t.Bits())
+
+			if err != nil {
+				return out, false
+			}
+
+			out.SetInt(n)
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(s, 10, t.Bits())
+
+			if err != nil {
+				return out, false
+			}
+
+			out.SetUint(n)
+
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(s, t.Bits())
+
+			// JSON numbers can't be NaN or infinite.
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+				return out, false
+			}
+
+			out.SetFloat(f)
+
+		case reflect.Bool:
+			b, err := strconv.ParseBool(s)
+
+			if err != nil {
+				return out, false
+			}
+
+			out.SetBool(b)
+
+		default:
+			return out, false
+		}
+
+		return out, true
+	}
+
+	if t.Kind() != reflect.String {
+		return out, false
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out.SetString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		out.SetString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		out.SetString(strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()))
+	default:
+		return out, false
+	}
+
+	return out, true
+}
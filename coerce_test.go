@@ -0,0 +1,139 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type coerced schema.FieldCoerced
+
+type TestStructCoerce struct {
+	Int      int
+	Uint8    uint8
+	Float    float64
+	Bool     bool
+	Str      string
+	Duration time.Duration
+	Ptr      *int
+}
+
+// Tests that CoercingCanConvert allows lossless conversions between strings and
+// numbers, and still rejects lossy ones.
+func TestCoercingCanConvert(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		expected []mismatch
+	}{
+		{
+			srcJson:  `{"Int":"26","Uint8":"255","Float":"2.5","Bool":"true","Str":26.5,"Duration":"1h30m","Ptr":"-3"}`,
+			expected: []mismatch{},
+		},
+		{
+			srcJson:  `{"Int":26,"Uint8":255,"Float":2.5,"Bool":false,"Str":"hi","Duration":1000,"Ptr":null}`,
+			expected: []mismatch{},
+		},
+		{
+			srcJson: `{"Int":"2.5","Uint8":"300","Float":"NaN","Bool":"yes","Str":true,"Duration":"soon","Ptr":"x"}`,
+			expected: []mismatch{
+				{Field: "Int", Expected: "int", Actual: "string"},
+				{Field: "Uint8", Expected: "uint8", Actual: "string"},
+				{Field: "Float", Expected: "float64", Actual: "string"},
+				{Field: "Bool", Expected: "bool", Actual: "string"},
+				{Field: "Str", Expected: "string", Actual: "bool"},
+				{Field: "Duration", Expected: "Duration", Actual: "string"},
+				{Field: "Ptr", Expected: "*int", Actual: "string"},
+			},
+		},
+		{
+			srcJson: `{"Uint8":"-1"}`,
+			expected: []mismatch{
+				{Field: "Uint8", Expected: "uint8", Actual: "string"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		opts := &schema.CompareOpts{ConvertibleFunc: schema.CoercingCanConvert}
+		r, _ := schema.CompareMapToStruct(&TestStructCoerce{}, src, opts)
+		require.JSONEq(t, toJson(test.expected), toJson(r.MismatchedFields), test.srcJson)
+	}
+}
+
+// Tests that coerced fields are reported in CoercedFields.
+func TestCompareMapToStruct_CoercedFields(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Int":"26","Float":2.5,"Str":3}`), &src)
+
+	opts := &schema.CompareOpts{ConvertibleFunc: schema.CoercingCanConvert}
+	r, _ := schema.CompareMapToStruct(&TestStructCoerce{}, src, opts)
+
+	expected := []coerced{
+		{Field: "Int", Expected: "int", Actual: "string"},
+		{Field: "Str", Expected: "string", Actual: "float64"},
+	}
+
+	require.JSONEq(t, toJson(expected), toJson(r.CoercedFields))
+	require.Equal(t, `converted "Int" from a string to an int`, r.CoercedFields[0].String())
+	require.Equal(t, `converted a float64 to a string`, r.CoercedFields[1].Message())
+	require.Nil(t, r.Errors())
+
+	// Without the coercing func the same fields are type mismatches.
+	r, _ = schema.CompareMapToStruct(&TestStructCoerce{}, src, nil)
+	require.Len(t, r.MismatchedFields, 2)
+	require.Empty(t, r.CoercedFields)
+}
+
+// Tests that DecodeMapToStruct decodes coerced values.
+func TestDecodeMapToStruct_Coerced(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Int":"26","Uint8":"7","Float":"2.5","Bool":"true","Str":26.5,"Duration":"1h30m","Ptr":"-3"}`), &src)
+
+	dst := TestStructCoerce{}
+	opts := &schema.CompareOpts{ConvertibleFunc: schema.CoercingCanConvert}
+	r, err := schema.DecodeMapToStruct(&dst, src, opts)
+
+	require.NoError(t, err)
+	require.Nil(t, r.Errors())
+
+	ptr := -3
+	require.Equal(t, TestStructCoerce{
+		Int:      26,
+		Uint8:    7,
+		Float:    2.5,
+		Bool:     true,
+		Str:      "26.5",
+		Duration: 90 * time.Minute,
+		Ptr:      &ptr,
+	}, dst)
+}
+
+// Tests that values aren't coerced without CoercingCanConvert, including Go integers
+// which reflect can convert to a string.
+func TestDecodeMapToStruct_NotCoercedByDefault(t *testing.T) {
+	src := map[string]interface{}{"Str": 65, "Int": "26"}
+
+	convertible := func(t reflect.Type, v reflect.Value) bool { return true }
+
+	for _, opts := range []*schema.CompareOpts{nil, {ConvertibleFunc: convertible}} {
+		dst := TestStructCoerce{}
+		r, _ := schema.DecodeMapToStruct(&dst, src, opts)
+
+		expected := []mismatch{
+			{Field: "Int", Expected: "int", Actual: "string"},
+			{Field: "Str", Expected: "string", Actual: "int"},
+		}
+
+		require.JSONEq(t, toJson(expected), toJson(r.MismatchedFields))
+		require.Empty(t, r.CoercedFields)
+		require.Equal(t, TestStructCoerce{}, dst)
+	}
+}
@@ -0,0 +1,497 @@
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Schema is a compiled description of a struct which can be compared against many
+// maps without walking the struct with reflection each time. A Schema is immutable
+// and safe to use from multiple goroutines.
+type Schema struct {
+	info *typeInfo
+	opts *CompareOpts
+
+	// patchOpts is a copy of opts used for comparing merge patches.
+	patchOpts *CompareOpts
+}
+
+// typeInfo is the compiled form of a dst type.
+type typeInfo struct {
+	typ reflect.Type
+
+	// fields is the list of struct fields, with embedded structs flattened.
+	fields []fieldInfo
+
+	// fieldNames is the set of names in fields.
+	fieldNames map[string]struct{}
+
+	// foldedNames maps the folded name of each field (see foldName) to its name. If
+	// two fields have the same folded name, the first field is used.
+	foldedNames map[string]string
+
+	// elem is the element type of a pointer, slice, array or map.
+	elem *typeInfo
+
+	// unmarshaler is true if the type implements json.Unmarshaler or
+	// encoding.TextUnmarshaler, in which case it's checked by unmarshaling the value.
+	unmarshaler bool
+
+	// wrapper is set if the type is a wrapper such as Nullable[T], in which case
+	// elem is the type of the wrapped value.
+	wrapper wrapperKind
+}
+
+// fieldInfo is a compiled struct field.
+type fieldInfo struct {
+	// name is the JSON name of the field.
+	name string
+
+	// index is the index sequence of the field for reflect.Value.FieldByIndex.
+	index []int
+
+	info *typeInfo
+
+	// rules are the validation rules from the field's schema tag.
+	rules []rule
+
+	// required and optional are set if the field is tagged as required or optional.
+	required bool
+	optional bool
+
+	// nullable and notNull are set if the field is tagged as nullable or notnull.
+	nullable bool
+	notNull  bool
+
+	// omitEmpty is set if the field has the json omitempty option.
+	omitEmpty bool
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// typeCache holds the compiler for each dst type and naming strategy
+// (map[typeKey]*compiler).
+var typeCache sync.Map
+
+// typeKey is the key of a compiled type in the cache. Since the same type has
+// different field names depending on the FieldNameFunc, the key includes the name
+// of the naming strategy.
+type typeKey struct {
+	typ    reflect.Type
+	naming string
+}
+
+/*
+Compile takes a pointer to a struct (dst) and returns a Schema which compares maps
+to it. The struct is only walked once, and the result is cached and shared with
+CompareMapToStruct. If a field has an invalid schema tag, an error wrapping
+ErrInvalidTag is returned.
+
+The options are copied, so changing opts after calling Compile does not affect the
+returned Schema.
+*/
+func Compile(dst interface{}, opts *CompareOpts) (*Schema, error) {
+	t := reflect.TypeOf(dst)
+
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, ErrInvalidDst
+	}
+
+	opts = withDefaults(opts)
+	nameFunc, key := fieldNameFunc(opts)
+	info, err := cachedTypeInfo(t.Elem(), nameFunc, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Schema{
+		info: info,
+		opts: opts,
+	}
+
+	patchOpts := *s.opts
+	patchOpts.patch = true
+	s.patchOpts = &patchOpts
+
+	return s, nil
+}
+
+// Compare compares src to the compiled struct. See CompareMapToStruct for details.
+func (s *Schema) Compare(src map[string]interface{}) (*CompareResults, error) {
+	return s.run(reflect.Value{}, src, s.opts)
+}
+
+// Decode compares src to the compiled struct and decodes it into dst, which must be
+// a pointer to the compiled struct. See DecodeMapToStruct for details.
+func (s *Schema) Decode(dst interface{}, src map[string]interface{}) (*CompareResults, error) {
+	v, err := s.dstValue(dst)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.run(v, src, s.opts)
+}
+
+// dstValue returns the struct which dst points to. dst must be a non-nil pointer to
+// the compiled struct, otherwise ErrInvalidDst is returned.
+func (s *Schema) dstValue(dst interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(dst)
+
+	if !v.IsValid() || v.Type() != reflect.PtrTo(s.info.typ) || v.IsNil() {
+		return reflect.Value{}, ErrInvalidDst
+	}
+
+	return v.Elem(), nil
+}
+
+// run compares src to the compiled struct, decoding it into dst if dst is valid.
+func (s *Schema) run(dst reflect.Value, src map[string]interface{}, opts *CompareOpts) (*CompareResults, error) {
+	if src == nil {
+		return nil, ErrNilSrc
+	}
+
+	results := newResults(opts)
+
+	compare(s.info, src, dst, nil, opts, results)
+	results.finish()
+
+	return results, nil
+}
+
+// newResults returns empty results for a comparison using opts.
+func newResults(opts *CompareOpts) *CompareResults {
+	return &CompareResults{
+		MismatchedFields:      []FieldMismatch{},
+		MissingFields:         []FieldMissing{},
+		UnknownFields:         []FieldUnknown{},
+		CoercedFields:         []FieldCoerced{},
+		InvalidFields:         []FieldInvalid{},
+		MissingRequiredFields: []FieldMissing{},
+		NullFields:            []FieldNull{},
+		Warnings:              []FieldWarning{},
+		opts:                  opts,
+	}
+}
+
+// withDefaults returns a copy of opts with any missing options set to their defaults.
+func withDefaults(opts *CompareOpts) *CompareOpts {
+	copied := CompareOpts{}
+
+	if opts != nil {
+		copied = *opts
+	}
+
+	if copied.ConvertibleReasonFunc == nil {
+		if copied.ConvertibleFunc == nil {
+			copied.ConvertibleReasonFunc = DefaultCanConvertReason
+		} else {
+			copied.ConvertibleReasonFunc = withReason(copied.ConvertibleFunc)
+		}
+	}
+	if copied.ConvertibleFunc == nil {
+		copied.ConvertibleFunc = DefaultCanConvert
+	}
+	copied.coerce = reflect.ValueOf(copied.ConvertibleFunc).Pointer() == reflect.ValueOf(CoercingCanConvert).Pointer()
+	if copied.TypeNameFunc == nil {
+		copied.TypeNameFunc = DetailedTypeName
+	}
+	if copied.MaxDepth <= 0 {
+		copied.MaxDepth = DefaultMaxDepth
+	}
+
+	return &copied
+}
+
+// cachedTypeInfo returns the compiled type info for t, compiling it with nameFunc if
+// it's not in the cache yet. naming is the cache key for nameFunc, and if it's empty
+// the type is compiled without using the cache.
+func cachedTypeInfo(t reflect.Type, nameFunc FieldNameFunc, naming string) (*typeInfo, error) {
+	if naming == "" {
+		c := newCompiler(t, nameFunc)
+		return c.root, c.err
+	}
+
+	key := typeKey{typ: t, naming: naming}
+
+	if c, ok := typeCache.Load(key); ok {
+		c := c.(*compiler)
+		return c.root, c.err
+	}
+
+	c, _ := typeCache.LoadOrStore(key, newCompiler(t, nameFunc))
+
+	return c.(*compiler).root, c.(*compiler).err
+}
+
+// compiler compiles a dst type and the types of its fields.
+type compiler struct {
+	root *typeInfo
+
+	// err is the first error found while compiling, such as an invalid schema tag.
+	err error
+
+	// seen holds the types which were already compiled, so that each type is only
+	// compiled once.
+	seen map[reflect.Type]*typeInfo
+
+	// fieldName returns the names of struct fields.
+	fieldName FieldNameFunc
+}
+
+// newCompiler compiles type t, using fieldName for the names of struct fields.
+func newCompiler(t reflect.Type, fieldName FieldNameFunc) *compiler {
+	c := &compiler{seen: make(map[reflect.Type]*typeInfo), fieldName: fieldName}
+	c.root = c.compile(t)
+
+	return c
+}
+
+// compile compiles type t.
+func (c *compiler) compile(t reflect.Type) *typeInfo {
+	if info, ok := c.seen[t]; ok {
+		return info
+	}
+
+	info := &typeInfo{typ: t}
+	c.seen[t] = info
+
+	// Wrapper types are compared using the type of the value they hold.
+	if info.wrapper = getWrapperKind(t); info.wrapper != notWrapper {
+		info.elem = c.compile(t.Field(0).Type)
+		return info
+	}
+
+	// Types that unmarshal themselves are opaque, e.g. time.Time is a struct but it
+	// shouldn't be compared field by field.
+	if isUnmarshaler(t) {
+		info.unmarshaler = true
+		return info
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		info.fieldNames = make(map[string]struct{})
+		info.foldedNames = make(map[string]string)
+		c.addFields(info, t)
+
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		info.elem = c.compile(t.Elem())
+	}
+
+	return info
+}
+
+// addFields adds the fields of struct type t to info. Fields are resolved the same
+// way as encoding/json: the fields of inlined structs (e.g. embedded structs) are
+// treated as if they were declared in t, and if more than one field has the same
+// name, the shallowest field wins, followed by a tagged field. If neither decides
+// it, none of the fields with that name are used.
+func (c *compiler) addFields(info *typeInfo, t reflect.Type) {
+	var fields []fieldCandidate
+
+	// The structs at the current and next depth, and how many times each struct
+	// type appears at that depth.
+	var current []embeddedStruct
+	next := []embeddedStruct{{typ: t}}
+	count, nextCount := map[reflect.Type]int{}, map[reflect.Type]int{t: 1}
+	visited := map[reflect.Type]bool{}
+
+	for len(next) > 0 {
+		current, next = next, nil
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, s := range current {
+			if visited[s.typ] {
+				continue
+			}
+
+			visited[s.typ] = true
+
+			for i := 0; i < s.typ.NumField(); i++ {
+				f := s.typ.Field(i)
+
+				if f.Anonymous {
+					// Embedded structs are used even if they're unexported, since
+					// their exported fields are promoted.
+					if !f.IsExported() && indirectType(f.Type).Kind() != reflect.Struct {
+						continue
+					}
+				} else if !f.IsExported() {
+					continue
+				}
+
+				fieldName, skip, tagOpts := c.fieldName(f)
+
+				if skip {
+					continue
+				}
+
+				index := make([]int, len(s.index), len(s.index)+1)
+				copy(index, s.index)
+				index = append(index, i)
+
+				ft := f.Type
+
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				// If the field is inlined (e.g. embedded) its fields are added at
+				// the next depth.
+				if tagOpts.Inline && ft.Kind() == reflect.Struct {
+					nextCount[ft]++
+
+					if nextCount[ft] == 1 {
+						next = append(next, embeddedStruct{typ: ft, index: index})
+					}
+
+					continue
+				}
+
+				fields = append(fields, fieldCandidate{
+					field:  f,
+					parent: s.typ,
+					name:   fieldName,
+					index:  index,
+					opts:   tagOpts,
+				})
+
+				// If the struct appeared more than once at this depth, its fields
+				// are duplicated so they cancel each other out.
+				if count[s.typ] > 1 {
+					fields = append(fields, fields[len(fields)-1])
+				}
+			}
+		}
+	}
+
+	for _, f := range dominantFields(fields) {
+		tag, err := parseSchemaTag(f.field.Tag.Get("schema"))
+
+		if err != nil && c.err == nil {
+			c.err = fmt.Errorf("%w: %s.%s: %v", ErrInvalidTag, f.parent.Name(), f.field.Name, err)
+		}
+
+		info.fields = append(info.fields, fieldInfo{
+			name:      f.name,
+			index:     f.index,
+			info:      c.compile(f.field.Type),
+			rules:     tag.rules,
+			required:  tag.required,
+			optional:  tag.optional,
+			nullable:  tag.nullable,
+			notNull:   tag.notNull,
+			omitEmpty: f.opts.OmitEmpty,
+		})
+		info.fieldNames[f.name] = struct{}{}
+
+		if _, ok := info.foldedNames[foldName(f.name)]; !ok {
+			info.foldedNames[foldName(f.name)] = f.name
+		}
+	}
+}
+
+// embeddedStruct is a struct whose fields are being added to a parent struct.
+type embeddedStruct struct {
+	typ reflect.Type
+
+	// index is the index sequence of the struct in the parent struct.
+	index []int
+}
+
+// fieldCandidate is a field which is added to a struct unless another field with the
+// same name is dominant.
+type fieldCandidate struct {
+	field reflect.StructField
+
+	// parent is the struct which declares the field.
+	parent reflect.Type
+
+	name  string
+	index []int
+	opts  TagOptions
+}
+
+// dominantFields returns the fields which win over any other field with the same
+// name, in the order they're declared.
+func dominantFields(fields []fieldCandidate) []fieldCandidate {
+	// Sort by name, then depth, then tagged fields first, then declaration order.
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+
+		if a.name != b.name {
+			return a.name < b.name
+		} else if len(a.index) != len(b.index) {
+			return len(a.index) < len(b.index)
+		} else if a.opts.Tagged != b.opts.Tagged {
+			return a.opts.Tagged
+		}
+
+		return lessIndex(a.index, b.index)
+	})
+
+	var out []fieldCandidate
+
+	for i := 0; i < len(fields); {
+		j := i + 1
+
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+
+		// The first field is dominant unless the next field is at the same depth
+		// and is also tagged (or also untagged).
+		if j-i == 1 || len(fields[i].index) < len(fields[i+1].index) || fields[i].opts.Tagged != fields[i+1].opts.Tagged {
+			out = append(out, fields[i])
+		}
+
+		i = j
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return lessIndex(out[i].index, out[j].index)
+	})
+
+	return out
+}
+
+// lessIndex returns true if the field at index sequence a is declared before b.
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+
+	return len(a) < len(b)
+}
+
+// indirectType returns the type that t points to if it's a pointer, otherwise t.
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+
+	return t
+}
+
+// isUnmarshaler returns true if t or a pointer to t implements json.Unmarshaler or
+// encoding.TextUnmarshaler.
+func isUnmarshaler(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return false
+	}
+
+	ptr := reflect.PtrTo(t)
+
+	return ptr.Implements(jsonUnmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
@@ -0,0 +1,198 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type benchAddress struct {
+	Country     string `json:"country"`
+	City        string `json:"city"`
+	AddressLine string `json:"address_line"`
+}
+
+type benchPerson struct {
+	FirstName string            `json:"first_name"`
+	LastName  string            `json:"last_name"`
+	Age       int               `json:"age"`
+	Email     *string           `json:"email"`
+	Tags      []string          `json:"tags"`
+	Scores    map[string]int    `json:"scores"`
+	Address   benchAddress      `json:"address"`
+	Previous  []benchAddress    `json:"previous"`
+	Meta      map[string]string `json:"meta"`
+}
+
+const benchPersonJson = `{
+	"first_name": "Jessie",
+	"last_name": "Smith",
+	"age": 26,
+	"email": null,
+	"tags": ["a", "b", "c"],
+	"scores": {"math": 90, "art": 75},
+	"address": {"country": "US", "city": "Boston", "address_line": "1 Main St"},
+	"previous": [{"country": "US", "city": "Denver", "address_line": "2 Main St"}],
+	"meta": {"source": "web"}
+}`
+
+// Tests that Compile returns an error if the dst isn't valid.
+func TestCompile_BadDstErrors(t *testing.T) {
+	var err error
+	v := "hello"
+
+	_, err = schema.Compile(123, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+
+	_, err = schema.Compile(&v, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+
+	_, err = schema.Compile(nil, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+
+	_, err = schema.Compile(TestStruct{}, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+
+	_, err = schema.Compile(&TestStruct{}, nil)
+	require.NoError(t, err)
+
+	_, err = schema.Compile((*TestStruct)(nil), nil)
+	require.NoError(t, err)
+}
+
+// Tests that Schema.Compare returns an error if the src isn't valid.
+func TestSchema_CompareBadSrcErrors(t *testing.T) {
+	s, _ := schema.Compile(&TestStruct{}, nil)
+
+	_, err := s.Compare(nil)
+	require.Equal(t, schema.ErrNilSrc, err)
+}
+
+// Tests that Schema.Compare returns the same results as CompareMapToStruct.
+func TestSchema_CompareMatchesCompareMapToStruct(t *testing.T) {
+	tests := []struct {
+		srcJson string
+		dst     interface{}
+	}{
+		{
+			srcJson: `{"Foo":true,"Baz":"","Qux":1}`,
+			dst:     &TestStruct{},
+		},
+		{
+			srcJson: `{"Foo":1.23,"Bar":true,"Butt":"hi"}`,
+			dst:     &TestStructEmbedded{},
+		},
+		{
+			srcJson: `{"User":{"Foo":"foo", "Baz":12}, "Cat":{"A":{"Baz":true}, "B":true}}`,
+			dst:     &TestStructNested{},
+		},
+		{
+			srcJson: `{"Addresses":[{"City":"a"}, {"City":true}, 5], "Point":[1, 2, 3]}`,
+			dst:     &TestStructSlice{},
+		},
+		{
+			srcJson: benchPersonJson,
+			dst:     &benchPerson{},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		s, err := schema.Compile(test.dst, nil)
+		require.NoError(t, err)
+
+		expected, _ := schema.CompareMapToStructUncached(test.dst, src, nil)
+		actual, _ := s.Compare(src)
+		require.JSONEq(t, toJson(expected), toJson(actual), test.srcJson)
+
+		actual, _ = schema.CompareMapToStruct(test.dst, src, nil)
+		require.JSONEq(t, toJson(expected), toJson(actual), test.srcJson)
+	}
+}
+
+// Tests that Compile copies the options so later changes don't affect the Schema.
+func TestCompile_CopiesOpts(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Foo":true}`), &src)
+
+	opts := &schema.CompareOpts{}
+	s, _ := schema.Compile(&TestStruct{}, opts)
+
+	opts.TypeNameFunc = func(t reflect.Type) string { return "changed" }
+
+	r, _ := s.Compare(src)
+	require.Equal(t, "string", r.MismatchedFields[0].Expected)
+	require.Nil(t, opts.ConvertibleFunc)
+}
+
+// Tests that a Schema can be used from multiple goroutines (run with -race).
+func TestSchema_CompareConcurrent(t *testing.T) {
+	s, _ := schema.Compile(&benchPerson{}, nil)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			src := make(map[string]interface{})
+			json.Unmarshal([]byte(benchPersonJson), &src)
+
+			for j := 0; j < 100; j++ {
+				r, _ := s.Compare(src)
+				require.Empty(t, r.MismatchedFields)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func benchSrc(b *testing.B) map[string]interface{} {
+	src := make(map[string]interface{})
+
+	if err := json.Unmarshal([]byte(benchPersonJson), &src); err != nil {
+		b.Fatal(err)
+	}
+
+	return src
+}
+
+func BenchmarkCompareMapToStruct_Uncached(b *testing.B) {
+	src := benchSrc(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		schema.CompareMapToStructUncached(&benchPerson{}, src, nil)
+	}
+}
+
+func BenchmarkCompareMapToStruct(b *testing.B) {
+	src := benchSrc(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		schema.CompareMapToStruct(&benchPerson{}, src, nil)
+	}
+}
+
+func BenchmarkSchema_Compare(b *testing.B) {
+	src := benchSrc(b)
+	s, _ := schema.Compile(&benchPerson{}, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		s.Compare(src)
+	}
+}
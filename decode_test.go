@@ -0,0 +1,143 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests that DecodeMapToStruct returns an error if the dst isn't valid.
+func TestDecodeMapToStruct_BadDstErrors(t *testing.T) {
+	var err error
+	m := make(map[string]interface{})
+
+	_, err = schema.DecodeMapToStruct(TestStruct{}, m, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+
+	_, err = schema.DecodeMapToStruct((*TestStruct)(nil), m, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+
+	_, err = schema.DecodeMapToStruct(&TestStruct{}, nil, nil)
+	require.Equal(t, schema.ErrNilSrc, err)
+
+	s, _ := schema.Compile(&TestStruct{}, nil)
+	_, err = s.Decode(&TestStructPtr{}, m)
+	require.Equal(t, schema.ErrInvalidDst, err)
+}
+
+type TestStructBytes struct {
+	B []byte
+}
+
+// Tests that DecodeMapToStruct populates dst the same way json.Unmarshal does when
+// there are no errors.
+func TestDecodeMapToStruct_MatchesUnmarshal(t *testing.T) {
+	tests := []struct {
+		srcJson string
+		dst     interface{}
+	}{
+		{
+			srcJson: `{"Foo":"foo","Bar":12,"Baz":3}`,
+			dst:     &TestStruct{},
+		},
+		{
+			srcJson: `{"Foo":"foo","Bar":12,"Baz":3.14,"Butt":true}`,
+			dst:     &TestStructEmbedded{},
+		},
+		{
+			srcJson: `{"Ptr":"hi"}`,
+			dst:     &TestStructPtr{},
+		},
+		{
+			srcJson: `{"a":"a","IgnoreMe":"x","WithOptions":"b","-":"c"}`,
+			dst:     &TestStructTags{},
+		},
+		{
+			srcJson: `{"User":{"Foo":"foo", "Bar":12, "Baz":12}, "Cat":{"A":{"Baz":"baz"}, "B":true, "C":"c"}}`,
+			dst:     &TestStructNested{},
+		},
+		{
+			srcJson: `{"Tags":["a", "b"], "Addresses":[{"City":"a"}, {}], "Ptrs":[1, null], "Grid":[[1, 2], []], "Point":[1, 2.5]}`,
+			dst:     &TestStructSlice{},
+		},
+		{
+			srcJson: `{"Scores":{"a":1, "b":2}, "Labels":{"home":{"City":"a"}, "work":null}, "ByID":{"12":"a", "-3":"b"}}`,
+			dst:     &TestStructMap{},
+		},
+		{
+			srcJson: `{"Created":"2021-06-30T12:00:00Z","Updated":"2021-07-01T00:00:00+02:00","IP":"10.0.0.1","Level":"info","ByIP":{"::1":1}}`,
+			dst:     &TestStructUnmarshaler{},
+		},
+		{
+			srcJson: benchPersonJson,
+			dst:     &benchPerson{},
+		},
+		{
+			srcJson: `{"B":"aGVsbG8="}`,
+			dst:     &TestStructBytes{},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		expected := reflect.New(reflect.TypeOf(test.dst).Elem()).Interface()
+		require.NoError(t, json.Unmarshal([]byte(test.srcJson), expected))
+
+		r, err := schema.DecodeMapToStruct(test.dst, src, nil)
+		require.NoError(t, err)
+		require.Nil(t, r.Errors(), test.srcJson)
+		require.Equal(t, expected, test.dst, test.srcJson)
+	}
+}
+
+// Tests that a string which isn't valid base64 is reported for a []byte.
+func TestCompareMapToStruct_InvalidBase64(t *testing.T) {
+	r, _ := schema.CompareMapToStruct(&TestStructBytes{}, map[string]interface{}{"B": "not base64!"}, nil)
+
+	require.Len(t, r.MismatchedFields, 1)
+	require.Equal(t, schema.ParseError, r.MismatchedFields[0].Reason)
+	require.Equal(t, "B", r.MismatchedFields[0].Field)
+}
+
+// Tests that DecodeMapToStruct returns the same results as CompareMapToStruct and
+// leaves mismatched fields untouched.
+func TestDecodeMapToStruct_Mismatches(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"User": {"Foo":"foo", "Bar":true}, "Cat":{"A":{"Baz":true}, "B":true}}`), &src)
+
+	dst := TestStructNested{}
+	dst.User.Bar = 5
+
+	expected, _ := schema.CompareMapToStruct(&TestStructNested{}, src, nil)
+	actual, err := schema.DecodeMapToStruct(&dst, src, nil)
+
+	require.NoError(t, err)
+	require.JSONEq(t, toJson(expected), toJson(actual))
+	require.Equal(t, "foo", dst.User.Foo)
+	require.Equal(t, 5, dst.User.Bar)
+	require.True(t, dst.Cat.B)
+	require.NotNil(t, dst.Cat.A)
+	require.Equal(t, "", dst.Cat.A.Baz)
+}
+
+// Tests that DecodeMapToStruct sets pointers to nil for null values and reuses
+// existing pointers otherwise.
+func TestDecodeMapToStruct_Pointers(t *testing.T) {
+	s := "old"
+	dst := TestStructPtr{Ptr: &s}
+
+	src := map[string]interface{}{"Ptr": "new"}
+	schema.DecodeMapToStruct(&dst, src, nil)
+	require.Equal(t, "new", s)
+	require.Equal(t, &s, dst.Ptr)
+
+	src = map[string]interface{}{"Ptr": nil}
+	schema.DecodeMapToStruct(&dst, src, nil)
+	require.Nil(t, dst.Ptr)
+}
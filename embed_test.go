@@ -0,0 +1,184 @@
+package schema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type EmbedA struct {
+	A      string
+	Shared string
+	Tag    string `json:"tag"`
+}
+
+type EmbedB struct {
+	B      string
+	Shared string
+	Tag    string
+}
+
+type EmbedTagged struct {
+	X string `json:"Shared"`
+}
+
+type embedUnexported struct {
+	U      string
+	hidden string
+}
+
+type EmbedInt int
+
+type EmbedWrap1 struct {
+	EmbedB
+}
+
+type EmbedWrap2 struct {
+	EmbedB
+}
+
+type EmbedTaggedStruct struct {
+	EmbedA `json:"base"`
+	C      string
+}
+
+type EmbedPtr struct {
+	*EmbedA
+	C string
+}
+
+type EmbedNonStruct struct {
+	EmbedInt
+	C string
+}
+
+type EmbedDepth struct {
+	EmbedA
+	Shared int
+}
+
+type EmbedAmbiguous struct {
+	EmbedA
+	EmbedB
+}
+
+type EmbedTaggedWins struct {
+	EmbedB
+	EmbedTagged
+}
+
+type EmbedUnexported struct {
+	embedUnexported
+	hidden string
+}
+
+type EmbedTwice struct {
+	EmbedWrap1
+	EmbedWrap2
+	C string
+}
+
+type EmbedPtrCycle struct {
+	*EmbedPtrCycle
+	C string
+}
+
+// Tests that DecodeMapToStruct resolves embedded fields the same way as encoding/json,
+// and that UnknownFields has the same keys encoding/json would reject with
+// DisallowUnknownFields.
+func TestDecodeMapToStruct_EmbeddedConformance(t *testing.T) {
+	tests := []struct {
+		srcJson string
+		dst     interface{}
+	}{
+		{srcJson: `{"base":{"A":"a","tag":"t"},"C":"c"}`, dst: &EmbedTaggedStruct{}},
+		{srcJson: `{"A":"a","C":"c"}`, dst: &EmbedTaggedStruct{}},
+		{srcJson: `{"A":"a","Shared":"s","tag":"t","C":"c"}`, dst: &EmbedPtr{}},
+		{srcJson: `{"C":"c"}`, dst: &EmbedPtr{}},
+		{srcJson: `{"EmbedInt":1,"C":"c"}`, dst: &EmbedNonStruct{}},
+		{srcJson: `{"A":"a","Shared":1}`, dst: &EmbedDepth{}},
+		{srcJson: `{"A":"a","B":"b","tag":"t","Tag":"T"}`, dst: &EmbedAmbiguous{}},
+		{srcJson: `{"Shared":"s"}`, dst: &EmbedAmbiguous{}},
+		{srcJson: `{"B":"b","Shared":"s"}`, dst: &EmbedTaggedWins{}},
+		{srcJson: `{"U":"u"}`, dst: &EmbedUnexported{}},
+		{srcJson: `{"hidden":"h"}`, dst: &EmbedUnexported{}},
+		{srcJson: `{"C":"c"}`, dst: &EmbedTwice{}},
+		{srcJson: `{"B":"b"}`, dst: &EmbedTwice{}},
+		{srcJson: `{"C":"c"}`, dst: &EmbedPtrCycle{}},
+	}
+
+	for _, test := range tests {
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		typ := reflect.TypeOf(test.dst).Elem()
+		expected := reflect.New(typ).Interface()
+		actual := reflect.New(typ).Interface()
+
+		require.NoError(t, json.Unmarshal([]byte(test.srcJson), expected))
+
+		r, err := schema.DecodeMapToStruct(actual, src, nil)
+		require.NoError(t, err, test.srcJson)
+		require.Nil(t, r.Errors(), test.srcJson)
+		require.Equal(t, expected, actual, "%T %s", test.dst, test.srcJson)
+
+		d := json.NewDecoder(bytes.NewReader([]byte(test.srcJson)))
+		d.DisallowUnknownFields()
+
+		if d.Decode(reflect.New(typ).Interface()) == nil {
+			require.Empty(t, r.UnknownFields, "%T %s", test.dst, test.srcJson)
+		} else {
+			require.NotEmpty(t, r.UnknownFields, "%T %s", test.dst, test.srcJson)
+		}
+	}
+}
+
+// Tests which fields are reported for structs with embedded fields.
+func TestCompareMapToStruct_EmbeddedFields(t *testing.T) {
+	tests := []struct {
+		dst      interface{}
+		expected []missing
+	}{
+		{
+			dst:      &EmbedTaggedStruct{},
+			expected: []missing{{Field: "base"}, {Field: "C"}},
+		},
+		{
+			dst:      &EmbedPtr{},
+			expected: []missing{{Field: "A"}, {Field: "Shared"}, {Field: "tag"}, {Field: "C"}},
+		},
+		{
+			dst:      &EmbedNonStruct{},
+			expected: []missing{{Field: "EmbedInt"}, {Field: "C"}},
+		},
+		{
+			dst:      &EmbedDepth{},
+			expected: []missing{{Field: "A"}, {Field: "tag"}, {Field: "Shared"}},
+		},
+		{
+			dst:      &EmbedAmbiguous{},
+			expected: []missing{{Field: "A"}, {Field: "tag"}, {Field: "B"}, {Field: "Tag"}},
+		},
+		{
+			dst:      &EmbedTaggedWins{},
+			expected: []missing{{Field: "B"}, {Field: "Tag"}, {Field: "Shared"}},
+		},
+		{
+			dst:      &EmbedUnexported{},
+			expected: []missing{{Field: "U"}},
+		},
+		{
+			dst:      &EmbedTwice{},
+			expected: []missing{{Field: "C"}},
+		},
+	}
+
+	for _, test := range tests {
+		r, _ := schema.CompareMapToStruct(test.dst, map[string]interface{}{}, nil)
+		require.JSONEq(t, toJson(test.expected), toJson(r.MissingFields), "%T", test.dst)
+	}
+}
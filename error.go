@@ -3,38 +3,206 @@ package schema
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
+	"sort"
 	"strings"
 )
 
-// MismatchError is represented as a map of field mismatch errors.
+// MismatchError is represented as a map of field mismatch errors. Nested fields are
+// nested maps, e.g. {"address": {"city": "..."}}. If a field has an error of its own
+// as well as errors in its nested fields, its own error is under the empty key.
 type MismatchError map[string]interface{}
 
+// Error returns the error of each field with its path, sorted by the path.
+// e.g: "address.city: expected a string but it's null, age: this field is required"
 func (err MismatchError) Error() string {
+	errs := err.Unwrap()
 	b := strings.Builder{}
 
-	for key, val := range err {
-		if s, ok := val.(string); ok {
-			b.WriteString(fmt.Sprintf("%s: %s", key, s))
+	for i, e := range errs {
+		if i > 0 {
 			b.WriteString(", ")
 		}
+
+		b.WriteString(e.Error())
+	}
+
+	return b.String()
+}
+
+// Fields returns the error message of each field, keyed by the field name with its
+// path, e.g. "address.city".
+func (err MismatchError) Fields() map[string]string {
+	fields := make(map[string]string)
+
+	for _, f := range err.flatten() {
+		fields[f.Field] = f.Message
 	}
 
-	s := b.String()
+	return fields
+}
+
+// Unwrap returns a *FieldError for each field, sorted by the path of the field. This
+// allows each field error to be inspected with errors.As.
+func (err MismatchError) Unwrap() []error {
+	fields := err.flatten()
+	errs := make([]error, len(fields))
 
-	// Remove the trailing ", "
-	if s != "" {
-		s = s[:len(s)-2]
+	for i := range fields {
+		errs[i] = fields[i]
 	}
 
-	return s
+	return errs
 }
 
 func (err MismatchError) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]interface{}(err))
 }
 
+// flatten returns the error of each field, sorted by the path of the field.
+func (err MismatchError) flatten() []*FieldError {
+	var errs []pathError
+
+	flattenErrors(&errs, err, nil)
+
+	// With PointerPath each field is a single key which is a JSON pointer. It's
+	// split into its tokens so that it sorts the same way as a nested path.
+	for i := range errs {
+		errs[i].sortPath = errs[i].path
+
+		if p := errs[i].path; len(p) == 1 && strings.HasPrefix(p[0], "/") {
+			errs[i].sortPath = pointerTokens(p[0])
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return comparePaths(errs[i].sortPath, errs[j].sortPath) < 0
+	})
+
+	fields := make([]*FieldError, len(errs))
+
+	for i, e := range errs {
+		fields[i] = &FieldError{
+			Field:   FieldNameWithPath(e.path[len(e.path)-1], e.path[:len(e.path)-1]),
+			Message: e.msg,
+		}
+	}
+
+	return fields
+}
+
+// pathError is an error message along with the full path of its field, and the
+// path used to sort it.
+type pathError struct {
+	path     []string
+	sortPath []string
+	msg      string
+}
+
+// flattenErrors adds each error message in m to errs. The empty key is the error of
+// the field that m belongs to (see setNestedError).
+func flattenErrors(errs *[]pathError, m map[string]interface{}, path []string) {
+	for key, val := range m {
+		switch val := val.(type) {
+		case string:
+			if key == "" && len(path) > 0 {
+				*errs = append(*errs, pathError{path: path, msg: val})
+			} else {
+				*errs = append(*errs, pathError{path: appendPath(path, key), msg: val})
+			}
+		case map[string]interface{}:
+			flattenErrors(errs, val, appendPath(path, key))
+		case MismatchError:
+			flattenErrors(errs, val, appendPath(path, key))
+		}
+	}
+}
+
+// FieldError is the error for a single field in a MismatchError.
+type FieldError struct {
+	// Field is the field name with its path, e.g. "address.city".
+	Field string
+
+	// Message is the error message, e.g. "this field is required".
+	Message string
+}
+
+func (err *FieldError) Error() string {
+	return err.Field + ": " + err.Message
+}
+
+// ValidationError is the error returned by CompareResults.Err. It holds a
+// *TypeMismatchError, *RuleViolationError, *MissingFieldError or *UnknownFieldError
+// for each field, which can be inspected with errors.As:
+//
+//	var mismatch *schema.TypeMismatchError
+//
+//	if errors.As(results.Err(), &mismatch) {
+//		fmt.Println(mismatch.Field, mismatch.Expected, mismatch.Actual)
+//	}
+type ValidationError struct {
+	// Errors is the error for each field.
+	Errors []error
+}
+
+// Error returns the error of each field, separated by commas.
+func (err *ValidationError) Error() string {
+	b := strings.Builder{}
+
+	for i, e := range err.Errors {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+
+		b.WriteString(e.Error())
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the error for each field.
+func (err *ValidationError) Unwrap() []error {
+	return err.Errors
+}
+
+// TypeMismatchError is the error for a field which has a type mismatch.
+type TypeMismatchError struct {
+	FieldMismatch
+}
+
+func (err *TypeMismatchError) Error() string {
+	return fieldNameWithPath(err.style, err.Field, err.Path) + ": " + err.Message()
+}
+
+// RuleViolationError is the error for a field which violates one of its rules.
+type RuleViolationError struct {
+	FieldInvalid
+}
+
+func (err *RuleViolationError) Error() string {
+	return fieldNameWithPath(err.style, err.Field, err.Path) + ": " + err.Message()
+}
+
+// MissingFieldError is the error for a required field which is missing.
+type MissingFieldError struct {
+	FieldMissing
+}
+
+func (err *MissingFieldError) Error() string {
+	return fieldNameWithPath(err.style, err.Field, err.Path) + ": " + err.Message()
+}
+
+// UnknownFieldError is the error for a field which isn't in dst, when unknown fields
+// are disallowed.
+type UnknownFieldError struct {
+	FieldUnknown
+}
+
+func (err *UnknownFieldError) Error() string {
+	return fieldNameWithPath(err.style, err.Field, err.Path) + ": " + err.Message()
+}
+
 var (
 	ErrInvalidDst = errors.New("dst must be a pointer to a struct")
 	ErrNilSrc     = errors.New("src must not be nil")
+	ErrInvalidTag = errors.New("invalid schema tag")
 )
@@ -0,0 +1,117 @@
+package schema_test
+
+import (
+	"errors"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+var nestedMismatchError = schema.MismatchError{
+	"name": "this field is required",
+	"address": map[string]interface{}{
+		"city": "expected a string but it's null",
+		"geo": map[string]interface{}{
+			"lat": "expected a float64 but it's a string",
+		},
+	},
+	"age": "must be at least 0",
+}
+
+// Tests that MismatchError.Error includes nested fields, sorted by path.
+func TestMismatchError_Error(t *testing.T) {
+	require.Equal(
+		t,
+		"address.city: expected a string but it's null, "+
+			"address.geo.lat: expected a float64 but it's a string, "+
+			"age: must be at least 0, "+
+			"name: this field is required",
+		nestedMismatchError.Error(),
+	)
+
+	err := schema.MismatchError{"address": map[string]interface{}{"city": "expected a string but it's null"}}
+	require.Equal(t, "address.city: expected a string but it's null", err.Error())
+
+	require.Equal(t, "", schema.MismatchError{}.Error())
+}
+
+// Tests that MismatchError.Fields flattens the nested fields.
+func TestMismatchError_Fields(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"name":            "this field is required",
+		"address.city":    "expected a string but it's null",
+		"address.geo.lat": "expected a float64 but it's a string",
+		"age":             "must be at least 0",
+	}, nestedMismatchError.Fields())
+}
+
+// Tests that each field error can be found with errors.As.
+func TestMismatchError_Unwrap(t *testing.T) {
+	var err error = nestedMismatchError
+
+	errs := nestedMismatchError.Unwrap()
+	require.Len(t, errs, 4)
+	require.Equal(t, &schema.FieldError{Field: "address.city", Message: "expected a string but it's null"}, errs[0])
+
+	var fieldErr *schema.FieldError
+	require.True(t, errors.As(err, &fieldErr))
+	require.Equal(t, "address.city", fieldErr.Field)
+}
+
+// Tests that CompareResults.Err returns typed errors for each field.
+func TestCompareResults_Err(t *testing.T) {
+	type S struct {
+		Name    string `json:"name" schema:"required"`
+		Age     int    `json:"age" schema:"min=0"`
+		Address struct {
+			City string `json:"city"`
+		} `json:"address"`
+	}
+
+	src := map[string]interface{}{
+		"age":     -1.0,
+		"address": map[string]interface{}{"city": 1.0},
+		"extra":   true,
+	}
+
+	r, _ := schema.CompareMapToStruct(&S{}, src, &schema.CompareOpts{DisallowUnknownFields: true})
+	err := r.Err()
+
+	require.Equal(
+		t,
+		"address.city: expected a string but it's a float64, "+
+			"age: must be at least 0, "+
+			"name: this field is required, "+
+			"extra: unknown field",
+		err.Error(),
+	)
+
+	var validationErr *schema.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Errors, 4)
+
+	var mismatch *schema.TypeMismatchError
+	require.True(t, errors.As(err, &mismatch))
+	require.Equal(t, "city", mismatch.Field)
+	require.Equal(t, []string{"address"}, mismatch.Path)
+	require.Equal(t, "string", mismatch.Expected)
+	require.Equal(t, "float64", mismatch.Actual)
+	require.Equal(t, schema.TypeMismatch, mismatch.Reason)
+
+	var violation *schema.RuleViolationError
+	require.True(t, errors.As(err, &violation))
+	require.Equal(t, "min", violation.Rule)
+	require.Equal(t, schema.OutOfRange, violation.Reason)
+
+	var missing *schema.MissingFieldError
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, "name", missing.Field)
+
+	var unknown *schema.UnknownFieldError
+	require.True(t, errors.As(err, &unknown))
+	require.Equal(t, "/extra", unknown.Pointer())
+
+	r, _ = schema.CompareMapToStruct(&S{}, map[string]interface{}{"name": "a", "extra": 1.0}, nil)
+	require.NoError(t, r.Err())
+}
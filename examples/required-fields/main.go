@@ -9,15 +9,16 @@ import (
 
 // Person is the model we are using.
 type Person struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Age       int    `json:"age"`
+	FirstName string `json:"first_name" schema:"required"`
+	LastName  string `json:"last_name" schema:"required"`
+	Age       int    `json:"age" schema:"required"`
+	Nickname  string `json:"nickname"`
 }
 
 // Response is used to generate a JSON response.
 type Response struct {
-	Errors interface{} `json:"errors,omitempty"`
-	OK     bool        `json:"ok"`
+	Errors error `json:"errors,omitempty"`
+	OK     bool  `json:"ok"`
 }
 
 func main() {
@@ -40,20 +41,12 @@ func main() {
 		panic(err)
 	}
 
+	// Notify the client of any errors. Required fields which are missing are
+	// included in the errors, and optional fields (nickname) are not.
+	errs := r.Errors()
 	resp := &Response{
-		OK: true,
-	}
-
-	// Notify the client of any errors.
-	if len(r.MissingFields) > 0 {
-		m := make(map[string]string)
-
-		for _, f := range r.MissingFields {
-			m[f.String()] = "this field is required"
-		}
-
-		resp.OK = false
-		resp.Errors = m
+		OK:     errs == nil,
+		Errors: errs,
 	}
 
 	respJson, _ := json.Marshal(resp)
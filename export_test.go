@@ -0,0 +1,17 @@
+package schema
+
+import "reflect"
+
+// CompareMapToStructUncached is the same as CompareMapToStruct except the struct is
+// compiled on every call instead of being loaded from the cache.
+func CompareMapToStructUncached(dst interface{}, src map[string]interface{}, opts *CompareOpts) (*CompareResults, error) {
+	s, err := Compile(dst, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.info = newCompiler(reflect.TypeOf(dst).Elem(), JSONFieldName).root
+
+	return s.Compare(src)
+}
@@ -0,0 +1,164 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WarningReason is the reason for a FieldWarning.
+type WarningReason int
+
+const (
+	// CaseFolded means the key only matched the field when ignoring case.
+	CaseFolded WarningReason = iota
+
+	// DuplicateKey means more than one key matched the field when ignoring case,
+	// and this key was ignored.
+	DuplicateKey
+)
+
+var warningReasonNames = map[WarningReason]string{
+	CaseFolded:   "case_folded",
+	DuplicateKey: "duplicate_key",
+}
+
+// String returns the reason as a machine readable code, e.g. "case_folded".
+func (r WarningReason) String() string {
+	return warningReasonNames[r]
+}
+
+// MarshalText marshals the reason as its code so it can be used in JSON.
+func (r WarningReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// FieldWarning represents a key in src which was matched to a field, but possibly
+// not in the way that was intended.
+type FieldWarning struct {
+	// Field is the JSON name of the field.
+	Field string
+
+	// Path is the full path to the field.
+	Path []string
+
+	// Key is the key in src.
+	Key string
+
+	// Reason is why the key was reported.
+	Reason WarningReason
+
+	style PathStyle
+}
+
+// Message returns the warning as a string.
+// e.g: `matched "FOO" ignoring case`
+func (f FieldWarning) Message() string {
+	if f.Reason == DuplicateKey {
+		return fmt.Sprintf(`%q also matches this field and was ignored`, f.Key)
+	}
+
+	return fmt.Sprintf(`matched %q ignoring case`, f.Key)
+}
+
+// String returns the warning as a string, including the field name with its path.
+// e.g: `"Cat.Foo" matched "FOO" ignoring case`
+func (f FieldWarning) String() string {
+	return fmt.Sprintf(`"%s" %s`, fieldNameWithPath(f.style, f.Field, f.Path), f.Message())
+}
+
+// Pointer returns the field name with its path as a JSON pointer.
+// e.g: "/Cat/Foo"
+func (f FieldWarning) Pointer() string {
+	return JSONPointer(f.Field, f.Path)
+}
+
+// foldKeys matches the keys in src to the fields of info the same way as
+// encoding/json: a key which is the same as a field name is preferred, otherwise
+// any key which is the same ignoring case is used. Returns the key to use for each
+// field name. If more than one key matches a field ignoring case, the first in
+// sorted order is used (encoding/json uses the last key in the document, but the
+// order isn't known once it's in a map).
+//
+// A warning is added to results for each key which only matches ignoring case, and
+// for each key which is ignored.
+func foldKeys(info *typeInfo, src map[string]interface{}, path []string, results *CompareResults) map[string]string {
+	keys := make([]string, 0, len(src))
+
+	for key := range src {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	// The keys which match each field, with an exact match first.
+	matches := make(map[string][]string)
+
+	for _, key := range keys {
+		if _, ok := info.fieldNames[key]; ok {
+			matches[key] = append([]string{key}, matches[key]...)
+		} else if name, ok := info.foldedNames[foldName(key)]; ok {
+			matches[name] = append(matches[name], key)
+		}
+	}
+
+	fieldKeys := make(map[string]string, len(matches))
+
+	for i := range info.fields {
+		name := info.fields[i].name
+		m := matches[name]
+
+		if len(m) == 0 {
+			continue
+		}
+
+		fieldKeys[name] = m[0]
+
+		if m[0] != name {
+			results.Warnings = append(results.Warnings, FieldWarning{Field: name, Path: path, Key: m[0], Reason: CaseFolded})
+		}
+
+		for _, key := range m[1:] {
+			results.Warnings = append(results.Warnings, FieldWarning{Field: name, Path: path, Key: key, Reason: DuplicateKey})
+		}
+	}
+
+	return fieldKeys
+}
+
+// foldName returns the name with each character folded the same way as
+// encoding/json, so that two names which are equal ignoring case have the same
+// folded name.
+func foldName(name string) string {
+	out := make([]byte, 0, len(name))
+
+	for _, r := range name {
+		if r < utf8.RuneSelf {
+			if 'a' <= r && r <= 'z' {
+				r -= 'a' - 'A'
+			}
+
+			out = append(out, byte(r))
+			continue
+		}
+
+		out = utf8.AppendRune(out, foldRune(r))
+	}
+
+	return string(out)
+}
+
+// foldRune returns the smallest rune of all the runes which are equal to r ignoring
+// case.
+func foldRune(r rune) rune {
+	for {
+		r2 := unicode.SimpleFold(r)
+
+		if r2 <= r {
+			return r2
+		}
+
+		r = r2
+	}
+}
@@ -0,0 +1,114 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type warning schema.FieldWarning
+
+type TestStructFold struct {
+	FirstName string `json:"first_name"`
+	Age       int
+	Kind      string
+	Upper     string `json:"UPPER"`
+	Lower     string `json:"upper"`
+	Address   struct {
+		City string
+	}
+}
+
+// Tests that CaseInsensitive decodes the same fields as json.Unmarshal.
+func TestDecodeMapToStruct_CaseInsensitiveMatchesUnmarshal(t *testing.T) {
+	tests := []string{
+		`{"FIRST_NAME":"a","age":1,"address":{"CITY":"b"}}`,
+		`{"first_name":"a","AGE":1}`,
+		`{"Kind":"k"}`,
+		`{"\u212aIND":"k"}`,
+		`{"Upper":"a"}`,
+		`{"UPPER":"a","upper":"b"}`,
+		`{"uPPER":"a","upper":"b"}`,
+		`{"firstname":"a","first-name":"b"}`,
+	}
+
+	for _, srcJson := range tests {
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(srcJson), &src)
+
+		expected := TestStructFold{}
+		actual := TestStructFold{}
+
+		require.NoError(t, json.Unmarshal([]byte(srcJson), &expected))
+
+		r, err := schema.DecodeMapToStruct(&actual, src, &schema.CompareOpts{CaseInsensitive: true})
+		require.NoError(t, err)
+		require.Nil(t, r.Errors(), srcJson)
+		require.Equal(t, expected, actual, srcJson)
+	}
+}
+
+// Tests that CaseInsensitive reports keys which only matched ignoring case, and keys
+// which were ignored.
+func TestCompareMapToStruct_CaseInsensitiveWarnings(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		warnings []warning
+		unknown  []unknown
+	}{
+		{
+			srcJson:  `{"first_name":"a","Age":1,"UPPER":"a","upper":"b"}`,
+			warnings: []warning{},
+			unknown:  []unknown{},
+		},
+		{
+			srcJson: `{"FIRST_NAME":"a","age":1,"address":{"CITY":"b"},"other":1}`,
+			warnings: []warning{
+				{Field: "first_name", Key: "FIRST_NAME", Reason: schema.CaseFolded},
+				{Field: "Age", Key: "age", Reason: schema.CaseFolded},
+				{Field: "Address", Key: "address", Reason: schema.CaseFolded},
+				{Field: "City", Key: "CITY", Reason: schema.CaseFolded, Path: []string{"Address"}},
+			},
+			unknown: []unknown{{Field: "other"}},
+		},
+		{
+			srcJson: `{"first_name":"a","First_Name":"b","AGE":1,"age":2}`,
+			warnings: []warning{
+				{Field: "first_name", Key: "First_Name", Reason: schema.DuplicateKey},
+				{Field: "Age", Key: "AGE", Reason: schema.CaseFolded},
+				{Field: "Age", Key: "age", Reason: schema.DuplicateKey},
+			},
+			unknown: []unknown{},
+		},
+	}
+
+	for _, test := range tests {
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(&TestStructFold{}, src, &schema.CompareOpts{CaseInsensitive: true})
+		require.JSONEq(t, toJson(test.warnings), toJson(r.Warnings), test.srcJson)
+		require.JSONEq(t, toJson(test.unknown), toJson(r.UnknownFields), test.srcJson)
+	}
+}
+
+// Tests that keys are matched exactly by default.
+func TestCompareMapToStruct_CaseSensitive(t *testing.T) {
+	src := map[string]interface{}{"FIRST_NAME": "a"}
+
+	r, _ := schema.CompareMapToStruct(&TestStructFold{}, src, nil)
+	require.JSONEq(t, toJson([]unknown{{Field: "FIRST_NAME"}}), toJson(r.UnknownFields))
+	require.Equal(t, "first_name", r.MissingFields[0].Field)
+	require.Empty(t, r.Warnings)
+}
+
+// Tests the warning messages.
+func TestFieldWarning_String(t *testing.T) {
+	w := schema.FieldWarning{Field: "city", Path: []string{"address"}, Key: "CITY", Reason: schema.CaseFolded}
+	require.Equal(t, `"address.city" matched "CITY" ignoring case`, w.String())
+
+	w.Reason = schema.DuplicateKey
+	require.Equal(t, `"CITY" also matches this field and was ignored`, w.Message())
+}
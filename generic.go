@@ -0,0 +1,36 @@
+package schema
+
+// CompareMap is the same as CompareMapToStruct, except the struct is given as the
+// type parameter T instead of a pointer. T must be a struct type, otherwise
+// ErrInvalidDst is returned.
+//
+//	results, err := schema.CompareMap[Person](src, nil)
+func CompareMap[T any](src map[string]interface{}, opts *CompareOpts) (*CompareResults, error) {
+	var dst *T
+
+	return CompareMapToStruct(dst, src, opts)
+}
+
+/*
+Decode compares src to the struct type T and, if there are no errors, returns src
+decoded into a T. This replaces calling CompareMapToStruct followed by json.Unmarshal.
+
+If the comparison finds errors (see CompareResults.Errors), Decode returns the zero
+value of T, the results, and the errors. If T is not a struct type, ErrInvalidDst is
+returned and the results are nil.
+
+	person, results, err := schema.Decode[Person](src, nil)
+*/
+func Decode[T any](src map[string]interface{}, opts *CompareOpts) (T, *CompareResults, error) {
+	var dst, zero T
+
+	results, err := DecodeMapToStruct(&dst, src, opts)
+
+	if err != nil {
+		return zero, nil, err
+	} else if err := results.Errors(); err != nil {
+		return zero, results, err
+	}
+
+	return dst, results, nil
+}
@@ -0,0 +1,70 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests that CompareMap returns an error if the type isn't a struct.
+func TestCompareMap_BadTypeErrors(t *testing.T) {
+	var err error
+	m := make(map[string]interface{})
+
+	_, err = schema.CompareMap[int](m, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+
+	_, err = schema.CompareMap[*TestStruct](m, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+
+	_, err = schema.CompareMap[TestStruct](nil, nil)
+	require.Equal(t, schema.ErrNilSrc, err)
+
+	_, err = schema.CompareMap[TestStruct](m, nil)
+	require.NoError(t, err)
+}
+
+// Tests that CompareMap returns the same results as CompareMapToStruct.
+func TestCompareMap(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"User": {"Foo":"foo", "Bar":true}, "Cat":{"A":{"Baz":true}}}`), &src)
+
+	expected, _ := schema.CompareMapToStruct(&TestStructNested{}, src, nil)
+	actual, err := schema.CompareMap[TestStructNested](src, nil)
+
+	require.NoError(t, err)
+	require.JSONEq(t, toJson(expected), toJson(actual))
+}
+
+// Tests that Decode populates the struct if there are no errors.
+func TestDecode(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"User": {"Foo":"foo", "Bar":12}, "Cat":{"A":{"Baz":"baz"}, "C":"c"}}`), &src)
+
+	actual, r, err := schema.Decode[TestStructNested](src, nil)
+
+	require.NoError(t, err)
+	require.Empty(t, r.MismatchedFields)
+	require.Equal(t, "foo", actual.User.Foo)
+	require.Equal(t, 12, actual.User.Bar)
+	require.Equal(t, "baz", actual.Cat.A.Baz)
+	require.Equal(t, "c", actual.Cat.C)
+}
+
+// Tests that Decode returns the zero value and the errors if there are errors.
+func TestDecode_Errors(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Foo":"foo", "Bar":true}`), &src)
+
+	actual, r, err := schema.Decode[TestStruct](src, nil)
+
+	require.Equal(t, TestStruct{}, actual)
+	require.Equal(t, r.Errors(), err)
+	require.Equal(t, schema.MismatchError{"Bar": "expected an int but it's a bool"}, err)
+
+	_, r, err = schema.Decode[int](src, nil)
+	require.Nil(t, r)
+	require.Equal(t, schema.ErrInvalidDst, err)
+}
@@ -0,0 +1,363 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONPatchResults contains the results of CompareJSONPatchToStruct.
+type JSONPatchResults struct {
+	// Operations has the result of each operation in the patch, in the same order.
+	Operations []OperationResult
+}
+
+// OperationResult is the result of checking a single JSON patch operation.
+type OperationResult struct {
+	// Index is the index of the operation in the patch.
+	Index int
+
+	// Op and Path are the operation's "op" and "path" members.
+	Op   string
+	Path string
+
+	// Error describes why the operation can't be applied to the struct, e.g. the
+	// path doesn't exist. It's empty if the operation is valid.
+	Error string `json:",omitempty"`
+
+	// Value is the result of comparing the operation's value to the type at its
+	// path. It's only set for add, replace and test operations.
+	Value *CompareResults `json:",omitempty"`
+}
+
+// Errors returns a MismatchError of the invalid operations, keyed by the index of
+// the operation. An operation's error is either a message, or the errors from
+// comparing its value (see CompareResults.Errors). If there were no errors, returns
+// nil.
+func (r *JSONPatchResults) Errors() error {
+	m := make(map[string]interface{})
+
+	for _, op := range r.Operations {
+		key := strconv.Itoa(op.Index)
+
+		if op.Error != "" {
+			m[key] = op.Error
+		} else if op.Value != nil {
+			if err := op.Value.Errors(); err != nil {
+				m[key] = map[string]interface{}(err.(MismatchError))
+			}
+		}
+	}
+
+	if len(m) == 0 {
+		return nil
+	}
+
+	return MismatchError(m)
+}
+
+/*
+CompareJSONPatchToStruct checks a JSON patch (RFC 6902) against a pointer to a struct
+(dst). The patch is the list of operations, e.g. the result of unmarshaling:
+
+	[{"op": "replace", "path": "/address/city", "value": "Paris"}]
+
+Each operation's path (and from) is a JSON pointer which must exist in dst, using
+the JSON names of the fields. Slice elements are referred to by index, or "-" to add
+to the end of a slice, and map values by key.
+
+The value of add, replace and test operations is compared to the type at the path
+the same way as CompareMapToStruct. A remove (or move) operation can only remove
+fields which aren't required (see CompareOpts.Required), and a move or copy operation
+must be between the same types.
+
+Since the patch is checked against the struct and not a document, indexes into a
+slice are not checked, and a path can't be checked beyond an interface{}.
+*/
+func CompareJSONPatchToStruct(dst interface{}, patch []map[string]interface{}, opts *CompareOpts) (*JSONPatchResults, error) {
+	s, err := Compile(dst, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.CompareJSONPatch(patch)
+}
+
+// CompareJSONPatch checks a JSON patch against the compiled struct. See
+// CompareJSONPatchToStruct for details.
+func (s *Schema) CompareJSONPatch(patch []map[string]interface{}) (*JSONPatchResults, error) {
+	if patch == nil {
+		return nil, ErrNilSrc
+	}
+
+	results := &JSONPatchResults{
+		Operations: make([]OperationResult, 0, len(patch)),
+	}
+
+	for i, op := range patch {
+		results.Operations = append(results.Operations, s.compareOperation(i, op))
+	}
+
+	return results, nil
+}
+
+// compareOperation checks a single JSON patch operation.
+func (s *Schema) compareOperation(index int, op map[string]interface{}) OperationResult {
+	result := OperationResult{Index: index}
+	result.Op, _ = op["op"].(string)
+
+	switch result.Op {
+	case "add", "remove", "replace", "move", "copy", "test":
+	default:
+		result.Error = `"op" must be one of add, remove, replace, move, copy or test`
+		return result
+	}
+
+	var ok bool
+
+	if result.Path, ok = op["path"].(string); !ok {
+		result.Error = `"path" must be a string`
+		return result
+	}
+
+	target, err := resolveJSONPointer(s.info, result.Path)
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	switch result.Op {
+	case "add", "replace", "test":
+		value, ok := op["value"]
+
+		if !ok {
+			result.Error = `"value" is required`
+		} else if result.Op == "add" {
+			result.Error = target.checkAdd()
+		} else if target.end {
+			result.Error = `"-" can only be used with add`
+		}
+
+		if result.Error != "" {
+			return result
+		}
+
+		if target.parent == reflect.Invalid {
+			if !isStringMap(reflect.ValueOf(value)) {
+				result.Error = `"value" must be an object`
+				return result
+			}
+		}
+
+		result.Value = newResults(s.opts)
+		target.compare(reflect.ValueOf(value), s.opts, result.Value)
+		result.Value.finish()
+
+	case "remove":
+		result.Error = target.checkRemove(s.opts)
+
+	case "move", "copy":
+		fromPath, ok := op["from"].(string)
+
+		if !ok {
+			result.Error = `"from" must be a string`
+			return result
+		}
+
+		from, err := resolveJSONPointer(s.info, fromPath)
+
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		} else if from.end {
+			result.Error = `"-" can only be used with add`
+			return result
+		}
+
+		if result.Op == "move" {
+			if strings.HasPrefix(result.Path, fromPath+"/") {
+				result.Error = fmt.Sprintf("%q can't be moved into itself", fromPath)
+				return result
+			} else if result.Error = from.checkRemove(s.opts); result.Error != "" {
+				return result
+			}
+		}
+
+		if result.Error = target.checkAdd(); result.Error != "" {
+			return result
+		}
+
+		fromType, toType := valueType(from.info), valueType(target.info)
+
+		if toType.Kind() != reflect.Interface && !fromType.AssignableTo(toType) {
+			result.Error = fmt.Sprintf("can't %s %s to %s", result.Op, s.opts.TypeNameFunc(fromType), s.opts.TypeNameFunc(toType))
+		}
+	}
+
+	return result
+}
+
+// jsonPointerTarget is the location in a struct that a JSON pointer refers to.
+type jsonPointerTarget struct {
+	// info is the type at the location.
+	info *typeInfo
+
+	// field is set if the location is a struct field.
+	field *fieldInfo
+
+	// parent is the kind of the type which holds the location, or reflect.Invalid if
+	// the location is the root.
+	parent reflect.Kind
+
+	// name is the last token of the pointer, and path is the tokens before it.
+	name string
+	path []string
+
+	// end is set if the last token is "-", which is the end of a slice.
+	end bool
+}
+
+// resolveJSONPointer returns the location in type info that pointer refers to. An
+// error is returned if pointer isn't valid or doesn't exist.
+func resolveJSONPointer(info *typeInfo, pointer string) (jsonPointerTarget, error) {
+	target := jsonPointerTarget{info: info, parent: reflect.Invalid}
+
+	if pointer == "" {
+		return target, nil
+	} else if pointer[0] != '/' {
+		return target, fmt.Errorf("%q is not a valid JSON pointer", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+
+	for i, token := range tokens {
+		// ~1 and ~0 are the escape sequences for "/" and "~". Any other "~" is invalid.
+		if strings.Count(token, "~") != strings.Count(token, "~0")+strings.Count(token, "~1") {
+			return target, fmt.Errorf("%q is not a valid JSON pointer", pointer)
+		}
+
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		if i > 0 {
+			target.path = appendPath(target.path, target.name)
+		}
+
+		target.name = token
+		target.field = nil
+		parent := indirectInfo(target.info)
+		target.parent = parent.typ.Kind()
+
+		if parent.unmarshaler {
+			return target, fmt.Errorf("path %q does not exist", pointer)
+		}
+
+		switch parent.typ.Kind() {
+		case reflect.Interface:
+			// Anything could be inside an interface, so the rest of the path is
+			// allowed.
+			target.info = parent
+
+		case reflect.Struct:
+			for j := range parent.fields {
+				if parent.fields[j].name == token {
+					target.field = &parent.fields[j]
+				}
+			}
+
+			if target.field == nil {
+				return target, fmt.Errorf("path %q does not exist", pointer)
+			}
+
+			target.info = target.field.info
+
+		case reflect.Slice, reflect.Array:
+			if token == "-" && i == len(tokens)-1 {
+				target.end = true
+			} else if n, ok := parseArrayIndex(token); !ok || (parent.typ.Kind() == reflect.Array && n >= parent.typ.Len()) {
+				return target, fmt.Errorf("path %q does not exist", pointer)
+			}
+
+			target.info = parent.elem
+
+		case reflect.Map:
+			if _, ok := parseMapKey(parent.typ.Key(), token); !ok {
+				return target, fmt.Errorf("path %q does not exist", pointer)
+			}
+
+			target.info = parent.elem
+
+		default:
+			return target, fmt.Errorf("path %q does not exist", pointer)
+		}
+	}
+
+	return target, nil
+}
+
+// checkAdd returns a message if a value can't be added at the target.
+func (t *jsonPointerTarget) checkAdd() string {
+	if t.parent == reflect.Array {
+		return "elements can't be added to a fixed size array"
+	}
+
+	return ""
+}
+
+// checkRemove returns a message if the value at the target can't be removed.
+func (t *jsonPointerTarget) checkRemove(opts *CompareOpts) string {
+	switch {
+	case t.parent == reflect.Invalid:
+		return "the root can't be removed"
+	case t.end:
+		return `"-" can only be used with add`
+	case t.parent == reflect.Array:
+		return "elements can't be removed from a fixed size array"
+	case t.field != nil && t.field.isRequired(opts.Required):
+		return fmt.Sprintf("%q is required and can't be removed", t.name)
+	}
+
+	return ""
+}
+
+// compare compares the src value (v) to the type at the target.
+func (t *jsonPointerTarget) compare(v reflect.Value, opts *CompareOpts, results *CompareResults) {
+	switch {
+	case t.parent == reflect.Invalid:
+		src, _ := stringMap(v)
+		compare(t.info, src, reflect.Value{}, nil, opts, results)
+	case t.field != nil:
+		compareField(t.field, v, reflect.Value{}, t.path, opts, results)
+	default:
+		compareValue(t.info, v, reflect.Value{}, t.name, t.path, opts, results)
+	}
+}
+
+// indirectInfo returns the type info of the value that a pointer or wrapper type
+// holds. If info is neither, info is returned.
+func indirectInfo(info *typeInfo) *typeInfo {
+	for info.wrapper != notWrapper || info.typ.Kind() == reflect.Ptr {
+		info = info.elem
+	}
+
+	return info
+}
+
+// valueType returns the type of the value that a pointer or wrapper type holds.
+func valueType(info *typeInfo) reflect.Type {
+	return indirectInfo(info).typ
+}
+
+// parseArrayIndex parses a JSON pointer array index, which must be a non-negative
+// integer without leading zeros.
+func parseArrayIndex(token string) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(token)
+
+	return n, err == nil && n >= 0
+}
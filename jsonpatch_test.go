@@ -0,0 +1,167 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type TestStructJSONPatch struct {
+	Name      string                            `json:"name" schema:"required"`
+	Age       int                               `json:"age" schema:"min=0"`
+	Nickname  *string                           `json:"nickname"`
+	Address   TestStructPatchAddress            `json:"address"`
+	Home      *TestStructPatchAddress           `json:"home"`
+	Addresses []TestStructPatchAddress          `json:"addresses"`
+	Point     [2]float64                        `json:"point"`
+	Places    map[string]TestStructPatchAddress `json:"places"`
+	Counts    map[int]int                       `json:"counts"`
+	Extra     interface{}                       `json:"extra"`
+	Escaped   string                            `json:"a/b~c"`
+}
+
+// Tests that CompareJSONPatchToStruct reports errors for each operation.
+func TestCompareJSONPatchToStruct(t *testing.T) {
+	tests := []struct {
+		patchJson string
+		errors    map[string]interface{}
+	}{
+		{
+			patchJson: `[]`,
+			errors:    nil,
+		},
+		{
+			patchJson: `[
+				{"op": "replace", "path": "/name", "value": "a"},
+				{"op": "add", "path": "/address/city", "value": "b"},
+				{"op": "replace", "path": "/home/country", "value": "c"},
+				{"op": "add", "path": "/addresses/-", "value": {"city": "d"}},
+				{"op": "replace", "path": "/addresses/0/city", "value": "e"},
+				{"op": "replace", "path": "/point/1", "value": 1.5},
+				{"op": "add", "path": "/places/x", "value": {"city": "f"}},
+				{"op": "remove", "path": "/places/x"},
+				{"op": "add", "path": "/counts/12", "value": 1},
+				{"op": "add", "path": "/extra/anything/at/all", "value": true},
+				{"op": "test", "path": "/a~1b~0c", "value": "g"},
+				{"op": "remove", "path": "/nickname"},
+				{"op": "copy", "from": "/address", "path": "/home"},
+				{"op": "move", "from": "/home", "path": "/addresses/0"},
+				{"op": "replace", "path": "", "value": {"name": "h"}}
+			]`,
+			errors: nil,
+		},
+		{
+			patchJson: `[
+				{"op": "update", "path": "/name", "value": "a"},
+				{"op": "add", "value": "a"},
+				{"op": "add", "path": "name", "value": "a"},
+				{"op": "add", "path": "/a~2b", "value": "a"},
+				{"op": "add", "path": "/unknown", "value": "a"},
+				{"op": "add", "path": "/name/x", "value": "a"},
+				{"op": "add", "path": "/addresses/01", "value": {}},
+				{"op": "add", "path": "/point/2", "value": 1},
+				{"op": "add", "path": "/counts/x", "value": 1},
+				{"op": "add", "path": "/name"}
+			]`,
+			errors: map[string]interface{}{
+				"0": `"op" must be one of add, remove, replace, move, copy or test`,
+				"1": `"path" must be a string`,
+				"2": `"name" is not a valid JSON pointer`,
+				"3": `"/a~2b" is not a valid JSON pointer`,
+				"4": `path "/unknown" does not exist`,
+				"5": `path "/name/x" does not exist`,
+				"6": `path "/addresses/01" does not exist`,
+				"7": `path "/point/2" does not exist`,
+				"8": `path "/counts/x" does not exist`,
+				"9": `"value" is required`,
+			},
+		},
+		{
+			patchJson: `[
+				{"op": "replace", "path": "/age", "value": "a"},
+				{"op": "replace", "path": "/age", "value": -1},
+				{"op": "add", "path": "/address/city", "value": 1},
+				{"op": "add", "path": "/addresses/-", "value": {"country": "a"}},
+				{"op": "replace", "path": "/addresses/-", "value": {"city": "a"}},
+				{"op": "add", "path": "/point/0", "value": 1},
+				{"op": "replace", "path": "/name", "value": null},
+				{"op": "replace", "path": "", "value": 1},
+				{"op": "replace", "path": "", "value": {"age": 1}}
+			]`,
+			errors: map[string]interface{}{
+				"0": map[string]interface{}{"age": "expected an int but it's a string"},
+				"1": map[string]interface{}{"age": "must be at least 0"},
+				"2": map[string]interface{}{"address": map[string]interface{}{"city": "expected a string but it's a float64"}},
+				"3": map[string]interface{}{"addresses": map[string]interface{}{"-": map[string]interface{}{"city": "this field is required"}}},
+				"4": `"-" can only be used with add`,
+				"5": "elements can't be added to a fixed size array",
+				"6": map[string]interface{}{"name": "expected a string but it's null"},
+				"7": `"value" must be an object`,
+				"8": map[string]interface{}{"name": "this field is required"},
+			},
+		},
+		{
+			patchJson: `[
+				{"op": "remove", "path": ""},
+				{"op": "remove", "path": "/name"},
+				{"op": "remove", "path": "/point/0"},
+				{"op": "remove", "path": "/addresses/-"},
+				{"op": "move", "from": "/name", "path": "/address/city"},
+				{"op": "move", "from": "/address", "path": "/address/city"},
+				{"op": "copy", "path": "/name"},
+				{"op": "copy", "from": "/nope", "path": "/name"},
+				{"op": "copy", "from": "/age", "path": "/name"},
+				{"op": "copy", "from": "/name", "path": "/point/0"}
+			]`,
+			errors: map[string]interface{}{
+				"0": "the root can't be removed",
+				"1": `"name" is required and can't be removed`,
+				"2": "elements can't be removed from a fixed size array",
+				"3": `"-" can only be used with add`,
+				"4": `"name" is required and can't be removed`,
+				"5": `"/address" can't be moved into itself`,
+				"6": `"from" must be a string`,
+				"7": `path "/nope" does not exist`,
+				"8": "can't copy int to string",
+				"9": "elements can't be added to a fixed size array",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var patch []map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(test.patchJson), &patch))
+
+		r, err := schema.CompareJSONPatchToStruct(&TestStructJSONPatch{}, patch, nil)
+		require.NoError(t, err)
+
+		if test.errors == nil {
+			require.Nil(t, r.Errors(), test.patchJson)
+		} else {
+			require.JSONEq(t, toJson(test.errors), toJson(r.Errors()), test.patchJson)
+		}
+	}
+}
+
+// Tests that each operation's result includes its op and path.
+func TestCompareJSONPatchToStruct_Operations(t *testing.T) {
+	var patch []map[string]interface{}
+	json.Unmarshal([]byte(`[{"op": "replace", "path": "/age", "value": 1}, {"op": "remove", "path": "/age"}]`), &patch)
+
+	r, err := schema.CompareJSONPatchToStruct(&TestStructJSONPatch{}, patch, nil)
+
+	require.NoError(t, err)
+	require.Len(t, r.Operations, 2)
+	require.Equal(t, schema.OperationResult{Index: 1, Op: "remove", Path: "/age"}, r.Operations[1])
+	require.Equal(t, 0, r.Operations[0].Index)
+	require.Equal(t, "replace", r.Operations[0].Op)
+	require.Empty(t, r.Operations[0].Value.MismatchedFields)
+
+	_, err = schema.CompareJSONPatchToStruct(&TestStructJSONPatch{}, nil, nil)
+	require.Equal(t, schema.ErrNilSrc, err)
+
+	_, err = schema.CompareJSONPatchToStruct(TestStructJSONPatch{}, patch, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+}
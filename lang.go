@@ -1,45 +1,51 @@
 package schema
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 )
 
 // DetailedTypeName takes a type and returns it verbatim.
 func DetailedTypeName(t reflect.Type) string {
-	if t.Kind() == reflect.Ptr {
-		return "*" + t.Elem().Name()
-	}
-
-	return t.Name()
+	return typeName(t, DetailedTypeName)
 }
 
 // SimpleTypeName takes a type and returns a more universal/generic name.
 // Floats are always "float", unsigned ints are always "uint", ints are always "int".
 func SimpleTypeName(t reflect.Type) string {
-	isPtr := t.Kind() == reflect.Ptr
-	elemType := t
-
-	if isPtr {
-		elemType = t.Elem()
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
 	}
 
-	out := elemType.Name()
+	return typeName(t, SimpleTypeName)
+}
 
-	switch elemType.Kind() {
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		out = "uint"
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		out = "int"
-	case reflect.Float32, reflect.Float64:
-		out = "float"
+// typeName returns the name of the type. Unnamed types such as pointers, slices,
+// arrays and maps are built from the names of their element types using nameFunc.
+// Named types always use their own name (e.g. net.IP is "IP", not "[]uint8").
+func typeName(t reflect.Type, nameFunc TypeNameFunc) string {
+	if t.Name() != "" {
+		return t.Name()
 	}
 
-	if isPtr {
-		out = "*" + out
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + nameFunc(t.Elem())
+	case reflect.Slice:
+		return "[]" + nameFunc(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), nameFunc(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", nameFunc(t.Key()), nameFunc(t.Elem()))
 	}
 
-	return out
+	return t.String()
 }
 
 // TypeNameStartsWithVowel returns true if the type name starts with a vowel.
@@ -87,3 +93,92 @@ func FieldNameWithPath(f string, path []string) string {
 
 	return b.String()
 }
+
+// JSONPointer returns the field name including the path as a JSON pointer (RFC 6901)
+// in the following format: /parent1/parent2/name
+//
+// "~" and "/" in the path are escaped as "~0" and "~1".
+func JSONPointer(f string, path []string) string {
+	b := strings.Builder{}
+
+	for _, p := range path {
+		b.WriteRune('/')
+		b.WriteString(jsonPointerEscaper.Replace(p))
+	}
+
+	b.WriteRune('/')
+	b.WriteString(jsonPointerEscaper.Replace(f))
+
+	return b.String()
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// fieldNameWithPath returns the field name including the path in the given style.
+func fieldNameWithPath(style PathStyle, f string, path []string) string {
+	if style == PointerPath {
+		return JSONPointer(f, path)
+	}
+
+	return FieldNameWithPath(f, path)
+}
+
+// lessFieldPath returns true if field a with path aPath sorts before field b with
+// path bPath. The paths are compared element by element, and elements which are
+// both indexes are compared as numbers so that "items.2" sorts before "items.10".
+func lessFieldPath(a string, aPath []string, b string, bPath []string) bool {
+	return comparePaths(appendPath(aPath, a), appendPath(bPath, b)) < 0
+}
+
+// pointerTokens splits a JSON pointer into its unescaped tokens, e.g. "/a~1b/0" ->
+// "a/b", "0".
+func pointerTokens(pointer string) []string {
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens
+}
+
+// comparePaths compares paths a and b element by element. A path sorts before any
+// longer path that starts with it.
+func comparePaths(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePathElements(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+
+	return len(a) - len(b)
+}
+
+// comparePathElements compares two elements of a path. Indexes are compared as
+// numbers, and anything else is compared as a string.
+func comparePathElements(a, b string) int {
+	if isIndex(a) && isIndex(b) {
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+	}
+
+	return strings.Compare(a, b)
+}
+
+// isIndex returns true if s is a non-negative integer.
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
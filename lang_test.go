@@ -1,6 +1,7 @@
 package schema_test
 
 import (
+	"net"
 	"reflect"
 	"testing"
 
@@ -89,6 +90,20 @@ func TestSimpleTypeName(t *testing.T) {
 			val:      &TestStruct{},
 			expected: "*TestStruct",
 		},
+
+		// composite types
+		{
+			val:      []int32{},
+			expected: "[]int",
+		},
+		{
+			val:      [3]*uint8{},
+			expected: "[3]*uint",
+		},
+		{
+			val:      map[string]float32{},
+			expected: "map[string]float",
+		},
 	}
 
 	for _, test := range tests {
@@ -97,6 +112,52 @@ func TestSimpleTypeName(t *testing.T) {
 	}
 }
 
+// Tests that DetailedTypeName returns the expected string.
+func TestDetailedTypeName(t *testing.T) {
+	tests := []struct {
+		val      interface{}
+		expected string
+	}{
+		{
+			val:      int32(0),
+			expected: "int32",
+		},
+		{
+			val:      new(uint8),
+			expected: "*uint8",
+		},
+		{
+			val:      &TestStruct{},
+			expected: "*TestStruct",
+		},
+		{
+			val:      []int32{},
+			expected: "[]int32",
+		},
+		{
+			val:      [2][]*TestStruct{},
+			expected: "[2][]*TestStruct",
+		},
+		{
+			val:      map[string]interface{}{},
+			expected: "map[string]interface {}",
+		},
+		{
+			val:      net.IP{},
+			expected: "IP",
+		},
+		{
+			val:      []net.IP{},
+			expected: "[]IP",
+		},
+	}
+
+	for _, test := range tests {
+		name := schema.DetailedTypeName(reflect.TypeOf(test.val))
+		require.Equal(t, test.expected, name)
+	}
+}
+
 // Tests that TypeNameStartsWithVowel returns the expected result.
 func TestTypeNameStartsWithVowel(t *testing.T) {
 	tests := []struct {
@@ -219,3 +280,42 @@ func TestFieldNameWithPath(t *testing.T) {
 		require.Equal(t, test.expected, actual)
 	}
 }
+
+// Tests that JSONPointer returns the expected result.
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     []string
+		expected string
+	}{
+		{
+			name:     "user",
+			expected: "/user",
+		},
+		{
+			name:     "email",
+			path:     []string{"user"},
+			expected: "/user/email",
+		},
+		{
+			name:     "sku",
+			path:     []string{"items", "3"},
+			expected: "/items/3/sku",
+		},
+		{
+			name:     "c~d",
+			path:     []string{"a.b", "a/b"},
+			expected: "/a.b/a~1b/c~0d",
+		},
+		{
+			name:     "",
+			path:     []string{"map"},
+			expected: "/map/",
+		},
+	}
+
+	for _, test := range tests {
+		actual := schema.JSONPointer(test.name, test.path)
+		require.Equal(t, test.expected, actual)
+	}
+}
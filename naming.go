@@ -0,0 +1,219 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// FieldNameFunc takes a struct field and returns the name of the field in src, and
+// the options from its tag. If skip is true the field is ignored.
+type FieldNameFunc func(f reflect.StructField) (name string, skip bool, opts TagOptions)
+
+// TagOptions are the options of a struct field's tag.
+type TagOptions struct {
+	// OmitEmpty is set if the field has the omitempty option. It's used by the
+	// OmitEmptyIsOptional policy.
+	OmitEmpty bool
+
+	// Inline is set if the fields of the field's struct should be treated as if
+	// they were declared in the parent struct, e.g. an embedded struct.
+	Inline bool
+
+	// Tagged is set if the name came from a tag. If two fields have the same name
+	// at the same depth, a tagged field wins over an untagged one.
+	Tagged bool
+}
+
+// JSONFieldName names fields the same way as encoding/json, using the "json" tag.
+// Embedded structs are inlined unless they're tagged with a name. This is the
+// default.
+func JSONFieldName(f reflect.StructField) (string, bool, TagOptions) {
+	name, skip, opts := tagFieldName("json", f)
+	opts.Inline = f.Anonymous && !opts.Tagged
+
+	return name, skip, opts
+}
+
+// YAMLFieldName names fields the same way as gopkg.in/yaml, using the "yaml" tag.
+// Untagged fields use the field name in lowercase, and embedded structs are only
+// inlined if they have the inline option.
+func YAMLFieldName(f reflect.StructField) (string, bool, TagOptions) {
+	return lowercaseTagFieldName("yaml", f)
+}
+
+// BSONFieldName names fields the same way as the MongoDB driver, using the "bson"
+// tag. Untagged fields use the field name in lowercase, and embedded structs are
+// only inlined if they have the inline option.
+func BSONFieldName(f reflect.StructField) (string, bool, TagOptions) {
+	return lowercaseTagFieldName("bson", f)
+}
+
+// MapstructureFieldName names fields the same way as github.com/mitchellh/mapstructure,
+// using the "mapstructure" tag. Embedded structs are only inlined if they have the
+// squash option.
+func MapstructureFieldName(f reflect.StructField) (string, bool, TagOptions) {
+	return tagFieldName("mapstructure", f)
+}
+
+// SnakeCaseFieldName ignores tags and converts the field name to snake case,
+// e.g. "UserID" -> "user_id". Embedded structs are inlined.
+func SnakeCaseFieldName(f reflect.StructField) (string, bool, TagOptions) {
+	return toSnakeCase(f.Name), false, TagOptions{Inline: f.Anonymous}
+}
+
+// CamelCaseFieldName ignores tags and converts the field name to camel case,
+// e.g. "UserID" -> "userID". Embedded structs are inlined.
+func CamelCaseFieldName(f reflect.StructField) (string, bool, TagOptions) {
+	return toCamelCase(f.Name), false, TagOptions{Inline: f.Anonymous}
+}
+
+// builtinFieldNameFuncs are the FieldNameFuncs which can be used as part of the type
+// cache key.
+var builtinFieldNameFuncs = map[string]FieldNameFunc{
+	"json":         JSONFieldName,
+	"yaml":         YAMLFieldName,
+	"bson":         BSONFieldName,
+	"mapstructure": MapstructureFieldName,
+	"snake":        SnakeCaseFieldName,
+	"camel":        CamelCaseFieldName,
+}
+
+// fieldNameFunc returns the FieldNameFunc to use for opts, and the key used to cache
+// the types compiled with it. The key is empty if the types can't be cached, which
+// is the case for a custom FieldNameFunc since there's no way to tell two closures
+// apart.
+func fieldNameFunc(opts *CompareOpts) (FieldNameFunc, string) {
+	if opts.FieldNameFunc != nil {
+		ptr := reflect.ValueOf(opts.FieldNameFunc).Pointer()
+
+		for name, f := range builtinFieldNameFuncs {
+			if reflect.ValueOf(f).Pointer() == ptr {
+				return f, "func:" + name
+			}
+		}
+
+		return opts.FieldNameFunc, ""
+	}
+
+	tag := opts.TagName
+
+	if tag == "" || tag == "json" {
+		return JSONFieldName, "tag:json"
+	}
+
+	nameFunc := func(f reflect.StructField) (string, bool, TagOptions) {
+		name, skip, opts := tagFieldName(tag, f)
+		opts.Inline = opts.Inline || (f.Anonymous && !opts.Tagged)
+
+		return name, skip, opts
+	}
+
+	return nameFunc, "tag:" + tag
+}
+
+// tagFieldName returns the name and options from the field's tag. If the field isn't
+// tagged with a name, the field name is used. The inline and squash options inline
+// the field.
+func tagFieldName(key string, f reflect.StructField) (name string, skip bool, opts TagOptions) {
+	tag := f.Tag.Get(key)
+
+	if tag == "-" {
+		return "", true, opts
+	}
+
+	name = tag
+
+	if i := strings.Index(tag, ","); i != -1 {
+		name = tag[:i]
+
+		for _, opt := range strings.Split(tag[i+1:], ",") {
+			switch opt {
+			case "omitempty":
+				opts.OmitEmpty = true
+			case "inline", "squash":
+				opts.Inline = true
+			}
+		}
+	}
+
+	if name == "" {
+		name = f.Name
+	} else {
+		opts.Tagged = true
+	}
+
+	return name, false, opts
+}
+
+// lowercaseTagFieldName is the same as tagFieldName, except fields which aren't
+// tagged with a name use the field name in lowercase.
+func lowercaseTagFieldName(key string, f reflect.StructField) (string, bool, TagOptions) {
+	name, skip, opts := tagFieldName(key, f)
+
+	if name == f.Name {
+		if tag := f.Tag.Get(key); tag == "" || tag[0] == ',' {
+			name = strings.ToLower(f.Name)
+		}
+	}
+
+	return name, skip, opts
+}
+
+// splitWords splits a Go identifier into words, treating a run of capitals as a
+// single word, e.g. "HTTPServerID" -> "HTTP", "Server", "ID".
+func splitWords(s string) []string {
+	var words []string
+
+	runes := []rune(s)
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+		if cur == '_' {
+			if start < i {
+				words = append(words, string(runes[start:i]))
+			}
+
+			start = i + 1
+		} else if unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower)) {
+			if start < i {
+				words = append(words, string(runes[start:i]))
+			}
+
+			start = i
+		}
+	}
+
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// toSnakeCase converts a Go identifier to snake case, e.g. "UserID" -> "user_id".
+func toSnakeCase(s string) string {
+	words := splitWords(s)
+
+	for i := range words {
+		words[i] = strings.ToLower(words[i])
+	}
+
+	return strings.Join(words, "_")
+}
+
+// toCamelCase converts a Go identifier to camel case, e.g. "UserID" -> "userID".
+func toCamelCase(s string) string {
+	words := splitWords(s)
+
+	if len(words) == 0 {
+		return s
+	}
+
+	words[0] = strings.ToLower(words[0])
+
+	return strings.Join(words, "")
+}
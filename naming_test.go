@@ -0,0 +1,149 @@
+package schema_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type TestStructNamingBase struct {
+	BaseField string
+}
+
+type TestStructNaming struct {
+	TestStructNamingBase `yaml:",inline" bson:",inline" mapstructure:",squash"`
+	UserID               string
+	FirstName            string `json:"first" yaml:"first_name" bson:"firstName" mapstructure:"first-name" toml:"FIRST"`
+	Nickname             string `json:",omitempty" yaml:",omitempty" bson:",omitempty" mapstructure:",omitempty"`
+	Ignored              string `json:"-" yaml:"-" bson:"-" mapstructure:"-" toml:"-"`
+}
+
+// missingFieldNames returns the names of the missing fields when comparing an empty
+// map to TestStructNaming.
+func missingFieldNames(t *testing.T, opts *schema.CompareOpts) []string {
+	r, err := schema.CompareMapToStruct(&TestStructNaming{}, map[string]interface{}{}, opts)
+	require.NoError(t, err)
+
+	names := make([]string, len(r.MissingFields))
+
+	for i, f := range r.MissingFields {
+		names[i] = f.String()
+	}
+
+	return names
+}
+
+// Tests that each naming strategy names the fields as expected.
+func TestCompareMapToStruct_FieldNames(t *testing.T) {
+	tests := []struct {
+		opts     *schema.CompareOpts
+		expected []string
+	}{
+		{
+			opts:     nil,
+			expected: []string{"BaseField", "UserID", "first", "Nickname"},
+		},
+		{
+			opts:     &schema.CompareOpts{FieldNameFunc: schema.JSONFieldName},
+			expected: []string{"BaseField", "UserID", "first", "Nickname"},
+		},
+		{
+			opts:     &schema.CompareOpts{FieldNameFunc: schema.YAMLFieldName},
+			expected: []string{"basefield", "userid", "first_name", "nickname"},
+		},
+		{
+			opts:     &schema.CompareOpts{FieldNameFunc: schema.BSONFieldName},
+			expected: []string{"basefield", "userid", "firstName", "nickname"},
+		},
+		{
+			opts:     &schema.CompareOpts{FieldNameFunc: schema.MapstructureFieldName},
+			expected: []string{"BaseField", "UserID", "first-name", "Nickname"},
+		},
+		{
+			opts:     &schema.CompareOpts{FieldNameFunc: schema.SnakeCaseFieldName},
+			expected: []string{"base_field", "user_id", "first_name", "nickname", "ignored"},
+		},
+		{
+			opts:     &schema.CompareOpts{FieldNameFunc: schema.CamelCaseFieldName},
+			expected: []string{"baseField", "userID", "firstName", "nickname", "ignored"},
+		},
+		{
+			opts:     &schema.CompareOpts{TagName: "toml"},
+			expected: []string{"BaseField", "UserID", "FIRST", "Nickname"},
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, missingFieldNames(t, test.opts))
+	}
+}
+
+// Tests that the yaml strategy only inlines embedded structs with the inline option.
+func TestCompareMapToStruct_FieldNamesNotInlined(t *testing.T) {
+	type S struct {
+		TestStructNamingBase
+	}
+
+	r, _ := schema.CompareMapToStruct(&S{}, map[string]interface{}{"testStructNamingBase": 1}, &schema.CompareOpts{
+		FieldNameFunc: schema.YAMLFieldName,
+	})
+
+	require.JSONEq(t, toJson([]missing{{Field: "teststructnamingbase"}}), toJson(r.MissingFields))
+}
+
+// Tests that the omitempty option is read by the naming strategy.
+func TestCompareMapToStruct_FieldNamesOmitEmpty(t *testing.T) {
+	r, _ := schema.CompareMapToStruct(&TestStructNaming{}, map[string]interface{}{}, &schema.CompareOpts{
+		FieldNameFunc: schema.YAMLFieldName,
+		Required:      schema.RequireAll | schema.OmitEmptyIsOptional,
+	})
+
+	require.JSONEq(t, toJson([]missing{{Field: "basefield"}, {Field: "userid"}, {Field: "first_name"}}), toJson(r.MissingRequiredFields))
+}
+
+// Tests that types compiled with different naming strategies aren't shared.
+func TestCompareMapToStruct_FieldNamesCache(t *testing.T) {
+	prefixed := func(prefix string) schema.FieldNameFunc {
+		return func(f reflect.StructField) (string, bool, schema.TagOptions) {
+			name, skip, opts := schema.JSONFieldName(f)
+			return prefix + strings.ToLower(name), skip, opts
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		require.Equal(t, "first", missingFieldNames(t, nil)[2])
+		require.Equal(t, "first_name", missingFieldNames(t, &schema.CompareOpts{TagName: "yaml"})[2])
+		require.Equal(t, "first-name", missingFieldNames(t, &schema.CompareOpts{TagName: "mapstructure"})[2])
+		require.Equal(t, "a_first", missingFieldNames(t, &schema.CompareOpts{FieldNameFunc: prefixed("a_")})[2])
+		require.Equal(t, "b_first", missingFieldNames(t, &schema.CompareOpts{FieldNameFunc: prefixed("b_")})[2])
+	}
+}
+
+// Tests the snake case and camel case conversions.
+func TestCaseFieldNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		snake string
+		camel string
+	}{
+		{name: "ID", snake: "id", camel: "id"},
+		{name: "Name", snake: "name", camel: "name"},
+		{name: "UserID", snake: "user_id", camel: "userID"},
+		{name: "HTTPServer", snake: "http_server", camel: "httpServer"},
+		{name: "Address2Line", snake: "address2_line", camel: "address2Line"},
+		{name: "Already_Snake", snake: "already_snake", camel: "alreadySnake"},
+	}
+
+	for _, test := range tests {
+		f := reflect.StructField{Name: test.name}
+
+		snake, _, _ := schema.SnakeCaseFieldName(f)
+		camel, _, _ := schema.CamelCaseFieldName(f)
+
+		require.Equal(t, test.snake, snake, test.name)
+		require.Equal(t, test.camel, camel, test.name)
+	}
+}
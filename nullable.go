@@ -0,0 +1,188 @@
+package schema
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+)
+
+// wrapperKind is the kind of wrapper type a struct is, if any. Wrapper types hold a
+// value in their first field and a flag in their second field.
+type wrapperKind int
+
+const (
+	notWrapper wrapperKind = iota
+
+	// nullableWrapper is a type such as Nullable[T] or sql.NullString. The flag is
+	// false if the value is null.
+	nullableWrapper
+
+	// optionalWrapper is Optional[T]. The flag is false if the value is absent.
+	optionalWrapper
+)
+
+// wrapper is implemented by the wrapper types in this package.
+type wrapper interface {
+	wrapperKind() wrapperKind
+}
+
+var (
+	wrapperType    = reflect.TypeOf((*wrapper)(nil)).Elem()
+	wrapperPkgPath = reflect.TypeOf(Nullable[int]{}).PkgPath()
+	sqlScannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+/*
+Nullable holds a value which may be null. When compared, null is always allowed and
+sets Valid to false. Any other value is compared against T and sets Valid to true.
+
+	type Person struct {
+		Nickname schema.Nullable[string] `json:"nickname"`
+	}
+
+The sql.Null* types (sql.NullString, sql.NullInt64, etc.) are treated the same way.
+*/
+type Nullable[T any] struct {
+	Value T
+	Valid bool
+}
+
+func (Nullable[T]) wrapperKind() wrapperKind {
+	return nullableWrapper
+}
+
+// MarshalJSON marshals the value, or null if it's not valid.
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(n.Value)
+}
+
+// UnmarshalJSON unmarshals the value, setting Valid to false if it's null.
+func (n *Nullable[T]) UnmarshalJSON(b []byte) error {
+	var zero T
+
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		n.Value, n.Valid = zero, false
+		return nil
+	}
+
+	if err := json.Unmarshal(b, &n.Value); err != nil {
+		return err
+	}
+
+	n.Valid = true
+
+	return nil
+}
+
+/*
+Optional holds a value which may be absent from src. Optional fields are never
+required (unless tagged with schema:"required"), and Present is set to true if the
+field is in src. Null is only allowed if T allows it, so a field which can be absent,
+null, or set can be written as:
+
+	type PatchPerson struct {
+		Nickname schema.Optional[schema.Nullable[string]] `json:"nickname"`
+	}
+*/
+type Optional[T any] struct {
+	Value   T
+	Present bool
+}
+
+func (Optional[T]) wrapperKind() wrapperKind {
+	return optionalWrapper
+}
+
+// MarshalJSON marshals the value.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON unmarshals the value and sets Present to true.
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &o.Value); err != nil {
+		return err
+	}
+
+	o.Present = true
+
+	return nil
+}
+
+// getWrapperKind returns the kind of wrapper type t is. Structs such as sql.NullString
+// which have two fields, the second being "Valid bool", and implement sql.Scanner
+// are also nullable wrappers.
+//
+// A struct which embeds a wrapper also implements wrapper through the promoted
+// method, so only the types from this package are wrappers.
+func getWrapperKind(t reflect.Type) wrapperKind {
+	if t.Kind() != reflect.Struct {
+		return notWrapper
+	} else if t.Implements(wrapperType) {
+		if t.PkgPath() != wrapperPkgPath {
+			return notWrapper
+		}
+
+		return reflect.Zero(t).Interface().(wrapper).wrapperKind()
+	}
+
+	if t.NumField() == 2 && t.Field(0).IsExported() && !t.Field(0).Anonymous && reflect.PtrTo(t).Implements(sqlScannerType) {
+		if valid := t.Field(1); valid.Name == "Valid" && valid.Type.Kind() == reflect.Bool {
+			return nullableWrapper
+		}
+	}
+
+	return notWrapper
+}
+
+// unwrap returns the type info of the value inside a wrapper type. If info isn't a
+// wrapper, info is returned.
+func unwrap(info *typeInfo) *typeInfo {
+	for info.wrapper != notWrapper {
+		info = info.elem
+	}
+
+	return info
+}
+
+// setNull sets dst to the value of a null field. For Optional this marks the field
+// as present.
+func setNull(info *typeInfo, dst reflect.Value) {
+	if !dst.IsValid() {
+		return
+	}
+
+	dst.Set(reflect.Zero(info.typ))
+
+	if info.wrapper == optionalWrapper {
+		dst.Field(1).SetBool(true)
+	}
+}
+
+// compareWrapper compares the src value (v) to a wrapper type. The value is compared
+// against the wrapped type, and the wrapper's flag is set if dst is valid.
+func compareWrapper(info *typeInfo, v reflect.Value, dst reflect.Value, fieldName string, path []string, opts *CompareOpts, results *CompareResults) bool {
+	if info.wrapper == nullableWrapper && !v.IsValid() {
+		setNull(info, dst)
+		return true
+	}
+
+	var valueDst reflect.Value
+
+	if dst.IsValid() {
+		valueDst = dst.Field(0)
+	}
+
+	ok := compareValue(info.elem, v, valueDst, fieldName, path, opts, results)
+
+	if ok && dst.IsValid() {
+		dst.Field(1).SetBool(true)
+	}
+
+	return ok
+}
@@ -0,0 +1,233 @@
+package schema_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type null schema.FieldNull
+
+type TestStructNullable struct {
+	Name     schema.Nullable[string]
+	Address  schema.Nullable[TestStruct]
+	Count    schema.Optional[int]
+	Nickname schema.Optional[schema.Nullable[string]]
+	SQL      sql.NullString
+	SQLInt   sql.NullInt32
+	Tagged   string  `schema:"nullable"`
+	NotNull  *string `schema:"notnull"`
+	Ptr      *string
+}
+
+// Tests that CompareMapToStruct allows null for nullable fields and reports them in
+// NullFields.
+func TestCompareMapToStruct_NullFields(t *testing.T) {
+	tests := []struct {
+		srcJson    string
+		mismatches []mismatch
+		nulls      []null
+	}{
+		{
+			srcJson:    `{}`,
+			mismatches: []mismatch{},
+			nulls:      []null{},
+		},
+		{
+			srcJson:    `{"Name":"a","Address":{"Foo":"a"},"Count":1,"Nickname":"b","SQL":"c","SQLInt":3,"Tagged":"d","NotNull":"e","Ptr":"f"}`,
+			mismatches: []mismatch{},
+			nulls:      []null{},
+		},
+		{
+			srcJson:    `{"Name":null,"Address":null,"Nickname":null,"SQL":null,"SQLInt":null,"Tagged":null,"Ptr":null}`,
+			mismatches: []mismatch{},
+			nulls: []null{
+				{Field: "Name"}, {Field: "Address"}, {Field: "Nickname"}, {Field: "SQL"},
+				{Field: "SQLInt"}, {Field: "Tagged"}, {Field: "Ptr"},
+			},
+		},
+		{
+			srcJson: `{"Name":1,"Address":{"Foo":true},"Count":null,"Nickname":2,"SQL":false,"SQLInt":1e10,"Tagged":3,"NotNull":null}`,
+			mismatches: []mismatch{
+				{Field: "Name", Expected: "string", Actual: "float64"},
+				{Field: "Foo", Expected: "string", Actual: "bool", Path: []string{"Address"}},
+				{Field: "Count", Expected: "int", Actual: "null", Reason: schema.NullNotAllowed},
+				{Field: "Nickname", Expected: "string", Actual: "float64"},
+				{Field: "SQL", Expected: "string", Actual: "bool"},
+				{Field: "SQLInt", Expected: "int32", Actual: "float64", Reason: schema.Overflow, Value: 1e10},
+				{Field: "Tagged", Expected: "string", Actual: "float64"},
+				{Field: "NotNull", Expected: "*string", Actual: "null", Reason: schema.NullNotAllowed},
+			},
+			nulls: []null{},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(&TestStructNullable{}, src, nil)
+		require.JSONEq(t, toJson(test.mismatches), toJson(r.MismatchedFields), test.srcJson)
+		require.JSONEq(t, toJson(test.nulls), toJson(r.NullFields), test.srcJson)
+	}
+}
+
+// Tests that DecodeMapToStruct sets the flags of wrapper types.
+func TestDecodeMapToStruct_Nullable(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Name":null,"Address":{"Foo":"a"},"Nickname":null,"SQL":"c","SQLInt":null,"Tagged":null}`), &src)
+
+	dst := TestStructNullable{Tagged: "old", SQLInt: sql.NullInt32{Int32: 1, Valid: true}}
+	r, err := schema.DecodeMapToStruct(&dst, src, nil)
+
+	require.NoError(t, err)
+	require.Nil(t, r.Errors())
+	require.Equal(t, schema.Nullable[string]{}, dst.Name)
+	require.Equal(t, schema.Nullable[TestStruct]{Value: TestStruct{Foo: "a"}, Valid: true}, dst.Address)
+	require.Equal(t, schema.Optional[int]{}, dst.Count)
+	require.Equal(t, schema.Optional[schema.Nullable[string]]{Present: true}, dst.Nickname)
+	require.Equal(t, sql.NullString{String: "c", Valid: true}, dst.SQL)
+	require.Equal(t, sql.NullInt32{}, dst.SQLInt)
+	require.Equal(t, "", dst.Tagged)
+
+	json.Unmarshal([]byte(`{"Count":5,"Nickname":"b"}`), &src)
+	schema.DecodeMapToStruct(&dst, src, nil)
+
+	require.Equal(t, schema.Optional[int]{Value: 5, Present: true}, dst.Count)
+	require.Equal(t, schema.Optional[schema.Nullable[string]]{Value: schema.Nullable[string]{Value: "b", Valid: true}, Present: true}, dst.Nickname)
+}
+
+// Tests that Optional fields are never required unless they're tagged as required.
+func TestCompareMapToStruct_OptionalNotRequired(t *testing.T) {
+	type S struct {
+		A schema.Optional[int]
+		B schema.Optional[int] `schema:"required"`
+		C int
+	}
+
+	r, _ := schema.CompareMapToStruct(&S{}, map[string]interface{}{}, &schema.CompareOpts{Required: schema.RequireAll})
+	require.JSONEq(t, toJson([]missing{{Field: "B"}, {Field: "C"}}), toJson(r.MissingRequiredFields))
+}
+
+// Tests that pointers to wrapper types are checked as the wrapper.
+func TestDecodeMapToStruct_PointerToWrapper(t *testing.T) {
+	type S struct {
+		N   *schema.Nullable[int]
+		O   *schema.Optional[string]
+		SQL *sql.NullString
+	}
+
+	dst := S{}
+	r, _ := schema.DecodeMapToStruct(&dst, map[string]interface{}{"N": 5.0, "O": "a", "SQL": "x"}, nil)
+
+	require.Nil(t, r.Errors())
+	require.Equal(t, &schema.Nullable[int]{Value: 5, Valid: true}, dst.N)
+	require.Equal(t, &schema.Optional[string]{Value: "a", Present: true}, dst.O)
+	require.Equal(t, &sql.NullString{String: "x", Valid: true}, dst.SQL)
+
+	r, _ = schema.DecodeMapToStruct(&dst, map[string]interface{}{"N": nil, "O": 1.0}, nil)
+
+	expected := []mismatch{
+		{Field: "O", Expected: "string", Actual: "float64"},
+	}
+
+	require.JSONEq(t, toJson(expected), toJson(r.MismatchedFields))
+	require.Nil(t, dst.N)
+}
+
+type TestStructEmbedsWrapper struct {
+	schema.Nullable[int]
+	Label string
+}
+
+type TestStructEmbedsSQL struct {
+	sql.NullString
+	Label string
+}
+
+// Tests that structs which embed a wrapper type aren't treated as wrappers, and are
+// decoded the same as json.Unmarshal.
+func TestDecodeMapToStruct_EmbeddedWrapper(t *testing.T) {
+	type S struct {
+		N   TestStructEmbedsWrapper
+		SQL TestStructEmbedsSQL
+	}
+
+	tests := []string{
+		`{"N":3}`,
+		`{"SQL":{"String":"a","Valid":true,"Label":"b"}}`,
+	}
+
+	for _, srcJson := range tests {
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(srcJson), &src)
+
+		expected := S{}
+		actual := S{}
+
+		require.NoError(t, json.Unmarshal([]byte(srcJson), &expected))
+
+		r, err := schema.DecodeMapToStruct(&actual, src, nil)
+		require.NoError(t, err)
+		require.Nil(t, r.Errors(), srcJson)
+		require.Equal(t, expected, actual, srcJson)
+	}
+}
+
+// Tests that slices, maps and interfaces only accept null if they're tagged as
+// nullable.
+func TestDecodeMapToStruct_NullableCollections(t *testing.T) {
+	type S struct {
+		Tags       []string
+		M          map[string]int
+		I          interface{}
+		TaggedTags []string       `schema:"nullable"`
+		TaggedM    map[string]int `schema:"nullable"`
+		TaggedI    interface{}    `schema:"nullable"`
+	}
+
+	src := map[string]interface{}{"Tags": nil, "M": nil, "I": nil, "TaggedTags": nil, "TaggedM": nil, "TaggedI": nil}
+	dst := S{TaggedTags: []string{"a"}, TaggedM: map[string]int{"a": 1}, TaggedI: 1}
+
+	r, _ := schema.DecodeMapToStruct(&dst, src, nil)
+
+	expected := []mismatch{
+		{Field: "Tags", Expected: "[]string", Actual: "null", Reason: schema.NullNotAllowed},
+		{Field: "M", Expected: "map[string]int", Actual: "null", Reason: schema.NullNotAllowed},
+		{Field: "I", Expected: "interface {}", Actual: "null", Reason: schema.NullNotAllowed},
+	}
+
+	require.JSONEq(t, toJson(expected), toJson(r.MismatchedFields))
+	require.Equal(t, S{}, dst)
+}
+
+// Tests that the wrapper types work with encoding/json.
+func TestNullable_JSON(t *testing.T) {
+	var v struct {
+		A schema.Nullable[int]
+		B schema.Nullable[int]
+		C schema.Optional[string]
+		D schema.Optional[string]
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"A":null,"B":2,"C":"c"}`), &v))
+	require.Equal(t, schema.Nullable[int]{}, v.A)
+	require.Equal(t, schema.Nullable[int]{Value: 2, Valid: true}, v.B)
+	require.Equal(t, schema.Optional[string]{Value: "c", Present: true}, v.C)
+	require.Equal(t, schema.Optional[string]{}, v.D)
+	require.JSONEq(t, `{"A":null,"B":2,"C":"c","D":""}`, toJson(v))
+}
+
+// Tests that a field can't be both nullable and notnull.
+func TestCompile_NullableAndNotNull(t *testing.T) {
+	_, err := schema.Compile(&struct {
+		A *string `schema:"nullable,notnull"`
+	}{}, nil)
+
+	require.True(t, errors.Is(err, schema.ErrInvalidTag))
+}
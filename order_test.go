@@ -0,0 +1,134 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type TestStructOrderBase struct {
+	B string
+	A string
+}
+
+type TestStructOrderNested struct {
+	Z int
+	Y int
+}
+
+type TestStructOrder struct {
+	Name string
+	TestStructOrderBase
+	Nested TestStructOrderNested
+	Items  []TestStructOrderNested
+	Age    int
+}
+
+const orderSrcJson = `{
+	"Age": "x",
+	"Items": [{}, {}, {"Z": "a"}, {}, {}, {}, {}, {}, {}, {}, {"Z": "a"}],
+	"Nested": {"Y": "a", "Z": "a"},
+	"A": 1,
+	"B": 1,
+	"Name": 1
+}`
+
+// fieldNames returns the field name with its path of each mismatch.
+func fieldNames(fields []schema.FieldMismatch) []string {
+	names := make([]string, len(fields))
+
+	for i, f := range fields {
+		names[i] = schema.FieldNameWithPath(f.Field, f.Path)
+	}
+
+	return names
+}
+
+// Tests that results are in the order the fields are declared, including embedded
+// and nested fields.
+func TestCompareMapToStruct_DeclarationOrder(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(orderSrcJson), &src)
+
+	for i := 0; i < 20; i++ {
+		r, _ := schema.CompareMapToStruct(&TestStructOrder{}, src, nil)
+
+		require.Equal(
+			t,
+			[]string{"Name", "B", "A", "Nested.Z", "Nested.Y", "Items.2.Z", "Items.10.Z", "Age"},
+			fieldNames(r.MismatchedFields),
+		)
+		require.Equal(
+			t,
+			"A: expected a string but it's a float64, "+
+				"Age: expected an int but it's a string, "+
+				"B: expected a string but it's a float64, "+
+				"Items.2.Z: expected an int but it's a string, "+
+				"Items.10.Z: expected an int but it's a string, "+
+				"Name: expected a string but it's a float64, "+
+				"Nested.Y: expected an int but it's a string, "+
+				"Nested.Z: expected an int but it's a string",
+			r.Errors().Error(),
+		)
+		require.Equal(
+			t,
+			"Name: expected a string but it's a float64, "+
+				"B: expected a string but it's a float64, "+
+				"A: expected a string but it's a float64, "+
+				"Nested.Z: expected an int but it's a string, "+
+				"Nested.Y: expected an int but it's a string, "+
+				"Items.2.Z: expected an int but it's a string, "+
+				"Items.10.Z: expected an int but it's a string, "+
+				"Age: expected an int but it's a string",
+			r.Err().Error(),
+		)
+	}
+}
+
+// Tests that SortByPath sorts the results by path.
+func TestCompareMapToStruct_SortByPath(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(orderSrcJson), &src)
+
+	opts := &schema.CompareOpts{SortByPath: true, Required: schema.RequireAll}
+	r, _ := schema.CompareMapToStruct(&TestStructOrder{}, src, opts)
+
+	require.Equal(
+		t,
+		[]string{"A", "Age", "B", "Items.2.Z", "Items.10.Z", "Name", "Nested.Y", "Nested.Z"},
+		fieldNames(r.MismatchedFields),
+	)
+
+	// Missing fields and mismatches are sorted together in Err.
+	errs := r.Err().(*schema.ValidationError).Errors
+
+	require.Equal(t, "A: expected a string but it's a float64", errs[0].Error())
+	require.Equal(t, "Age: expected an int but it's a string", errs[1].Error())
+	require.Equal(t, "B: expected a string but it's a float64", errs[2].Error())
+	require.Equal(t, "Items.0.Y: this field is required", errs[3].Error())
+	require.Equal(t, "Items.0.Z: this field is required", errs[4].Error())
+	require.Equal(t, "Items.1.Y: this field is required", errs[5].Error())
+}
+
+// Tests that errors keyed by JSON pointers are sorted the same as dotted paths.
+func TestCompareMapToStruct_PointerPathOrder(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(orderSrcJson), &src)
+
+	r, _ := schema.CompareMapToStruct(&TestStructOrder{}, src, &schema.CompareOpts{PathStyle: schema.PointerPath})
+
+	require.Equal(
+		t,
+		"/A: expected a string but it's a float64, "+
+			"/Age: expected an int but it's a string, "+
+			"/B: expected a string but it's a float64, "+
+			"/Items/2/Z: expected an int but it's a string, "+
+			"/Items/10/Z: expected an int but it's a string, "+
+			"/Name: expected a string but it's a float64, "+
+			"/Nested/Y: expected an int but it's a string, "+
+			"/Nested/Z: expected an int but it's a string",
+		r.Errors().Error(),
+	)
+}
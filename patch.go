@@ -0,0 +1,114 @@
+package schema
+
+import "reflect"
+
+/*
+ComparePatchToStruct compares a JSON merge patch (RFC 7386) to a struct. It works the
+same as CompareMapToStruct, except:
+
+  - Fields which are missing from the patch are not reported, since they are left
+    unchanged.
+  - Null deletes a field, so it's only allowed if the field can be null (a pointer,
+    Nullable[T] or a field tagged schema:"nullable").
+  - Nested structs and maps are compared as patches too. Null deletes a key from a
+    map regardless of the map's value type, and an object is merged into the object
+    held by an interface{}.
+  - Slices and arrays replace the existing value, so their elements are compared as
+    complete values and can have missing fields.
+*/
+func ComparePatchToStruct(dst interface{}, patch map[string]interface{}, opts *CompareOpts) (*CompareResults, error) {
+	s, err := Compile(dst, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ComparePatch(patch)
+}
+
+/*
+ApplyMergePatch validates a JSON merge patch (RFC 7386) and applies it to dst, which
+must be a pointer to an existing struct value. The patch is compared the same way as
+ComparePatchToStruct.
+
+If the patch has any errors (see CompareResults.Errors), dst is not changed and the
+results are returned along with the errors. Otherwise the fields in the patch are
+decoded into dst: nested structs and maps are merged, nulls set the field to its zero
+value (or delete the map key), and everything else is replaced.
+
+	person := loadPerson()
+	results, err := schema.ApplyMergePatch(&person, patch, nil)
+*/
+func ApplyMergePatch(dst interface{}, patch map[string]interface{}, opts *CompareOpts) (*CompareResults, error) {
+	s, err := Compile(dst, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ApplyPatch(dst, patch)
+}
+
+// ComparePatch compares a JSON merge patch to the compiled struct. See
+// ComparePatchToStruct for details.
+func (s *Schema) ComparePatch(patch map[string]interface{}) (*CompareResults, error) {
+	return s.run(reflect.Value{}, patch, s.patchOpts)
+}
+
+// ApplyPatch validates a JSON merge patch and applies it to dst, which must be a
+// pointer to the compiled struct. See ApplyMergePatch for details.
+func (s *Schema) ApplyPatch(dst interface{}, patch map[string]interface{}) (*CompareResults, error) {
+	v, err := s.dstValue(dst)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The patch is checked before it's applied so that dst is never left partially
+	// patched.
+	results, err := s.ComparePatch(patch)
+
+	if err != nil {
+		return nil, err
+	} else if err := results.Errors(); err != nil {
+		return results, err
+	}
+
+	return s.run(v, patch, s.patchOpts)
+}
+
+// mergePatch returns the result of merging the patch into target as described by
+// RFC 7386. If the patch isn't an object it replaces target, otherwise its fields are
+// merged recursively and null fields are deleted. target is not modified.
+//
+// depth is the number of objects which can be nested in the patch, including the
+// patch itself. Returns false if the patch is nested deeper than that.
+func mergePatch(target interface{}, patch interface{}, depth int) (interface{}, bool) {
+	p, ok := stringMap(reflect.ValueOf(patch))
+
+	if !ok {
+		return patch, true
+	} else if depth <= 0 {
+		return nil, false
+	}
+
+	t, _ := stringMap(reflect.ValueOf(target))
+	merged := make(map[string]interface{}, len(t)+len(p))
+
+	for k, v := range t {
+		merged[k] = v
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+
+		if merged[k], ok = mergePatch(merged[k], v, depth-1); !ok {
+			return nil, false
+		}
+	}
+
+	return merged, true
+}
@@ -0,0 +1,218 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type TestStructPatchAddress struct {
+	City    string `json:"city" schema:"required"`
+	Country string `json:"country"`
+}
+
+type TestStructPatch struct {
+	Name      string                            `json:"name" schema:"required"`
+	Age       int                               `json:"age" schema:"min=0"`
+	Nickname  *string                           `json:"nickname"`
+	Bio       string                            `json:"bio" schema:"nullable"`
+	Email     schema.Nullable[string]           `json:"email"`
+	Address   TestStructPatchAddress            `json:"address"`
+	Home      *TestStructPatchAddress           `json:"home"`
+	Addresses []TestStructPatchAddress          `json:"addresses"`
+	Labels    map[string]string                 `json:"labels"`
+	Places    map[string]TestStructPatchAddress `json:"places"`
+}
+
+// Tests that ComparePatchToStruct doesn't report missing fields, and only allows null
+// for fields which can be deleted.
+func TestComparePatchToStruct(t *testing.T) {
+	tests := []struct {
+		srcJson    string
+		mismatches []mismatch
+		required   []missing
+	}{
+		{
+			srcJson:    `{}`,
+			mismatches: []mismatch{},
+			required:   []missing{},
+		},
+		{
+			srcJson:    `{"age":2,"address":{"country":"a"},"home":{},"places":{"a":{"country":"b"}}}`,
+			mismatches: []mismatch{},
+			required:   []missing{},
+		},
+		{
+			srcJson:    `{"nickname":null,"bio":null,"email":null,"home":null,"labels":{"a":null},"places":{"a":null}}`,
+			mismatches: []mismatch{},
+			required:   []missing{},
+		},
+		{
+			srcJson: `{"name":null,"age":null,"address":null,"addresses":null,"labels":{"a":1}}`,
+			mismatches: []mismatch{
+				{Field: "name", Expected: "string", Actual: "null", Reason: schema.NullNotAllowed},
+				{Field: "age", Expected: "int", Actual: "null", Reason: schema.NullNotAllowed},
+				{Field: "address", Expected: "TestStructPatchAddress", Actual: "null", Reason: schema.NullNotAllowed},
+				{Field: "addresses", Expected: "[]TestStructPatchAddress", Actual: "null", Reason: schema.NullNotAllowed},
+				{Field: "a", Expected: "string", Actual: "float64", Path: []string{"labels"}},
+			},
+			required: []missing{},
+		},
+		{
+			srcJson:    `{"addresses":[{"country":"a"}]}`,
+			mismatches: []mismatch{},
+			required:   []missing{{Field: "city", Path: []string{"addresses", "0"}}},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, err := schema.ComparePatchToStruct(&TestStructPatch{}, src, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, toJson(test.mismatches), toJson(r.MismatchedFields), test.srcJson)
+		require.JSONEq(t, toJson(test.required), toJson(r.MissingRequiredFields), test.srcJson)
+	}
+}
+
+// Tests that ApplyMergePatch merges the patch into dst.
+func TestApplyMergePatch(t *testing.T) {
+	nickname := "nick"
+	dst := TestStructPatch{
+		Name:      "name",
+		Age:       10,
+		Nickname:  &nickname,
+		Bio:       "bio",
+		Email:     schema.Nullable[string]{Value: "a@b.c", Valid: true},
+		Address:   TestStructPatchAddress{City: "city", Country: "country"},
+		Home:      &TestStructPatchAddress{City: "home", Country: "country"},
+		Addresses: []TestStructPatchAddress{{City: "a"}, {City: "b"}},
+		Labels:    map[string]string{"a": "a", "b": "b"},
+		Places:    map[string]TestStructPatchAddress{"a": {City: "a", Country: "a"}},
+	}
+
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{
+		"age": 11,
+		"nickname": null,
+		"bio": null,
+		"email": null,
+		"address": {"country": "new"},
+		"home": {"city": "new"},
+		"addresses": [{"city": "c"}],
+		"labels": {"a": null, "c": "c"},
+		"places": {"a": {"city": "new"}, "b": {"country": "b"}}
+	}`), &src)
+
+	r, err := schema.ApplyMergePatch(&dst, src, nil)
+
+	require.NoError(t, err)
+	require.Nil(t, r.Errors())
+	require.Equal(t, TestStructPatch{
+		Name:      "name",
+		Age:       11,
+		Email:     schema.Nullable[string]{},
+		Address:   TestStructPatchAddress{City: "city", Country: "new"},
+		Home:      &TestStructPatchAddress{City: "new", Country: "country"},
+		Addresses: []TestStructPatchAddress{{City: "c"}},
+		Labels:    map[string]string{"b": "b", "c": "c"},
+		Places: map[string]TestStructPatchAddress{
+			"a": {City: "new", Country: "a"},
+			"b": {Country: "b"},
+		},
+	}, dst)
+}
+
+// Tests that objects are merged into maps and interfaces without a struct type the
+// same way as RFC 7386.
+func TestApplyMergePatch_Untyped(t *testing.T) {
+	type S struct {
+		Extra map[string]interface{}
+		Any   interface{}
+	}
+
+	dst := S{
+		Extra: map[string]interface{}{"a": map[string]interface{}{"y": 2.0, "z": 3.0}, "b": 1.0},
+		Any:   map[string]interface{}{"y": 2.0, "z": map[string]interface{}{"k": 1.0}},
+	}
+
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{
+		"Extra": {"a": {"x": 1, "z": null}, "c": {"d": null}},
+		"Any": {"x": [1, null], "y": null, "z": {"l": 2}}
+	}`), &src)
+
+	r, err := schema.ApplyMergePatch(&dst, src, nil)
+
+	require.NoError(t, err)
+	require.Nil(t, r.Errors())
+	require.Equal(t, S{
+		Extra: map[string]interface{}{
+			"a": map[string]interface{}{"x": 1.0, "y": 2.0},
+			"b": 1.0,
+			"c": map[string]interface{}{},
+		},
+		Any: map[string]interface{}{
+			"x": []interface{}{1.0, nil},
+			"z": map[string]interface{}{"k": 1.0, "l": 2.0},
+		},
+	}, dst)
+}
+
+// Tests that an object merged into an interface is limited to MaxDepth, including a
+// patch which contains itself.
+func TestApplyMergePatch_UntypedMaxDepth(t *testing.T) {
+	type S struct {
+		Any interface{}
+	}
+
+	cyclic := map[string]interface{}{"a": 1.0}
+	cyclic["self"] = cyclic
+
+	deep := map[string]interface{}{"a": 1.0}
+
+	for i := 0; i < 4; i++ {
+		deep = map[string]interface{}{"b": deep}
+	}
+
+	opts := &schema.CompareOpts{MaxDepth: 5}
+
+	for _, patch := range []map[string]interface{}{{"Any": cyclic}, {"Any": deep}} {
+		dst := S{Any: "old"}
+		r, err := schema.ApplyMergePatch(&dst, patch, opts)
+
+		require.Error(t, err)
+		require.Len(t, r.MismatchedFields, 1)
+		require.Equal(t, schema.DepthExceeded, r.MismatchedFields[0].Reason)
+		require.Equal(t, S{Any: "old"}, dst)
+	}
+
+	// One level less fits.
+	dst := S{}
+	_, err := schema.ApplyMergePatch(&dst, map[string]interface{}{"Any": deep["b"]}, opts)
+	require.NoError(t, err)
+}
+
+// Tests that ApplyMergePatch doesn't change dst if the patch has errors.
+func TestApplyMergePatch_Errors(t *testing.T) {
+	dst := TestStructPatch{Name: "name", Age: 10, Labels: map[string]string{"a": "a"}}
+
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"name":"new","age":-1,"labels":{"a":null}}`), &src)
+
+	r, err := schema.ApplyMergePatch(&dst, src, nil)
+
+	require.Error(t, err)
+	require.Equal(t, r.Errors(), err)
+	require.Equal(t, TestStructPatch{Name: "name", Age: 10, Labels: map[string]string{"a": "a"}}, dst)
+
+	_, err = schema.ApplyMergePatch(TestStructPatch{}, src, nil)
+	require.Equal(t, schema.ErrInvalidDst, err)
+
+	_, err = schema.ApplyMergePatch(&dst, nil, nil)
+	require.Equal(t, schema.ErrNilSrc, err)
+}
@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ProblemContentType is the media type of a Problem.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document describing the errors in
+// CompareResults.
+type Problem struct {
+	// Type is a URI which identifies the type of problem. If it's empty, the type is
+	// "about:blank".
+	Type string `json:"type,omitempty"`
+
+	// Title is a short summary of the type of problem.
+	Title string `json:"title"`
+
+	// Status is the HTTP status code.
+	Status int `json:"status"`
+
+	// Detail is an explanation of this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is a URI which identifies this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Errors is an extension member which lists the error for each field.
+	Errors []ProblemError `json:"errors"`
+}
+
+// ProblemError is the error for a single field in a Problem.
+type ProblemError struct {
+	// Pointer is the JSON pointer to the field, e.g. "/address/city".
+	Pointer string `json:"pointer"`
+
+	// Code is the reason for the error. Type mismatches and rule violations use the
+	// code of their MismatchReason (e.g. "type_mismatch" or "out_of_range"), missing
+	// required fields use "required" and unknown fields use "unknown_field".
+	Code string `json:"code"`
+
+	// Detail is the error message for the field.
+	Detail string `json:"detail"`
+}
+
+/*
+NewProblem converts the errors in results into an RFC 7807 problem with the given
+HTTP status, which is usually http.StatusBadRequest or http.StatusUnprocessableEntity.
+The problem has the same errors as CompareResults.Errors, which means unknown fields
+are only included if CompareOpts.DisallowUnknownFields is set.
+
+The title is the status text. The type, detail and instance can be changed before
+the problem is written.
+*/
+func NewProblem(results *CompareResults, status int) *Problem {
+	p := &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Errors: []ProblemError{},
+	}
+
+	for _, f := range results.MismatchedFields {
+		p.Errors = append(p.Errors, ProblemError{Pointer: f.Pointer(), Code: f.Reason.String(), Detail: f.Message()})
+	}
+
+	for _, f := range results.InvalidFields {
+		p.Errors = append(p.Errors, ProblemError{Pointer: f.Pointer(), Code: f.Reason.String(), Detail: f.Message()})
+	}
+
+	for _, f := range results.MissingRequiredFields {
+		p.Errors = append(p.Errors, ProblemError{Pointer: f.Pointer(), Code: "required", Detail: f.Message()})
+	}
+
+	if results.opts != nil && results.opts.DisallowUnknownFields {
+		for _, f := range results.UnknownFields {
+			p.Errors = append(p.Errors, ProblemError{Pointer: f.Pointer(), Code: "unknown_field", Detail: f.Message()})
+		}
+	}
+
+	switch len(p.Errors) {
+	case 0:
+	case 1:
+		p.Detail = "1 field is invalid"
+	default:
+		p.Detail = fmt.Sprintf("%d fields are invalid", len(p.Errors))
+	}
+
+	return p
+}
+
+// Write writes the problem to w as application/problem+json, using the problem's
+// status as the HTTP status.
+func (p *Problem) Write(w http.ResponseWriter) error {
+	b, err := json.Marshal(p)
+
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+// WriteProblem converts the errors in results into a problem (see NewProblem) and
+// writes it to w with the given HTTP status.
+//
+//	if results.Errors() != nil {
+//		schema.WriteProblem(w, results, http.StatusUnprocessableEntity)
+//		return
+//	}
+func WriteProblem(w http.ResponseWriter, results *CompareResults, status int) error {
+	return NewProblem(results, status).Write(w)
+}
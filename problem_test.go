@@ -0,0 +1,60 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests that NewProblem includes the same errors as CompareResults.Errors.
+func TestNewProblem(t *testing.T) {
+	type S struct {
+		Name    string `json:"name" schema:"required"`
+		Age     int    `json:"age" schema:"min=0"`
+		Address struct {
+			City string `json:"city"`
+		} `json:"address"`
+	}
+
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"age":-1,"address":{"city":1},"extra":true}`), &src)
+
+	r, _ := schema.CompareMapToStruct(&S{}, src, nil)
+	p := schema.NewProblem(r, http.StatusUnprocessableEntity)
+
+	require.JSONEq(t, `{
+		"title": "Unprocessable Entity",
+		"status": 422,
+		"detail": "3 fields are invalid",
+		"errors": [
+			{"pointer": "/address/city", "code": "type_mismatch", "detail": "expected a string but it's a float64"},
+			{"pointer": "/age", "code": "out_of_range", "detail": "must be at least 0"},
+			{"pointer": "/name", "code": "required", "detail": "this field is required"}
+		]
+	}`, toJson(p))
+
+	r, _ = schema.CompareMapToStruct(&S{}, src, &schema.CompareOpts{DisallowUnknownFields: true})
+	p = schema.NewProblem(r, http.StatusBadRequest)
+
+	require.Equal(t, "Bad Request", p.Title)
+	require.Equal(t, "4 fields are invalid", p.Detail)
+	require.Equal(t, schema.ProblemError{Pointer: "/extra", Code: "unknown_field", Detail: "unknown field"}, p.Errors[3])
+
+	r, _ = schema.CompareMapToStruct(&S{}, map[string]interface{}{"name": "a"}, nil)
+	require.JSONEq(t, `{"title":"Bad Request","status":400,"errors":[]}`, toJson(schema.NewProblem(r, http.StatusBadRequest)))
+}
+
+// Tests that WriteProblem writes the problem as application/problem+json.
+func TestWriteProblem(t *testing.T) {
+	r, _ := schema.CompareMapToStruct(&TestStruct{}, map[string]interface{}{"Foo": 1.0}, nil)
+	w := httptest.NewRecorder()
+
+	require.NoError(t, schema.WriteProblem(w, r, http.StatusUnprocessableEntity))
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Equal(t, schema.ProblemContentType, w.Header().Get("Content-Type"))
+	require.JSONEq(t, toJson(schema.NewProblem(r, http.StatusUnprocessableEntity)), w.Body.String())
+}
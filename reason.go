@@ -0,0 +1,200 @@
+package schema
+
+import (
+	"math"
+	"reflect"
+)
+
+// MismatchReason describes why a src value can't be converted to a dst type.
+type MismatchReason int
+
+const (
+	// TypeMismatch means the src type isn't compatible with the dst type.
+	TypeMismatch MismatchReason = iota
+
+	// Truncation means the src value is a number with a fractional part but the dst
+	// type is an integer, e.g. 1.5 -> int.
+	Truncation
+
+	// Negative means the src value is a negative number but the dst type is unsigned.
+	Negative
+
+	// Overflow means the src value is a number that's too large (or small) to fit
+	// in the dst type, e.g. 300 -> uint8.
+	Overflow
+
+	// NullNotAllowed means the src value is null but the dst type isn't nullable.
+	NullNotAllowed
+
+	// ParseError means the dst type implements json.Unmarshaler or
+	// encoding.TextUnmarshaler and returned an error when unmarshaling the src value,
+	// e.g. "yesterday" -> time.Time.
+	ParseError
+
+	// OutOfRange means the src value violates a min or max rule.
+	OutOfRange
+
+	// InvalidLength means the src value violates a len, minlen, maxlen, minitems
+	// or maxitems rule.
+	InvalidLength
+
+	// PatternMismatch means the src value doesn't match a pattern rule.
+	PatternMismatch
+
+	// NotInEnum means the src value isn't one of the options of an enum rule.
+	NotInEnum
+
+	// DepthExceeded means the src value is an object or array which is nested deeper
+	// than CompareOpts.MaxDepth. Its contents aren't checked.
+	DepthExceeded
+
+	// LengthMismatch means the src value is an array with a different number of
+	// elements than the dst fixed size array, e.g. [1, 2, 3] -> [2]int.
+	LengthMismatch
+)
+
+var mismatchReasonNames = map[MismatchReason]string{
+	TypeMismatch:    "type_mismatch",
+	Truncation:      "truncation",
+	Negative:        "negative",
+	Overflow:        "overflow",
+	NullNotAllowed:  "null_not_allowed",
+	ParseError:      "parse_error",
+	OutOfRange:      "out_of_range",
+	InvalidLength:   "invalid_length",
+	PatternMismatch: "pattern_mismatch",
+	NotInEnum:       "not_in_enum",
+	DepthExceeded:   "depth_exceeded",
+	LengthMismatch:  "length_mismatch",
+}
+
+// String returns the reason as a machine readable code, e.g. "type_mismatch".
+func (r MismatchReason) String() string {
+	return mismatchReasonNames[r]
+}
+
+// MarshalText marshals the reason as its code so it can be used in JSON.
+func (r MismatchReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// hasValue returns true if the src value is reported with a mismatch of this reason.
+// These are the reasons where the type is compatible but the value itself isn't.
+func (r MismatchReason) hasValue() bool {
+	switch r {
+	case Truncation, Negative, Overflow, ParseError:
+		return true
+	}
+
+	return false
+}
+
+// ConvertibleReasonFunc is the same as ConvertibleFunc, except it also returns why v
+// isn't convertible to t. The reason is ignored if ok is true.
+type ConvertibleReasonFunc func(t reflect.Type, v reflect.Value) (ok bool, reason MismatchReason)
+
+// DefaultCanConvertReason is the same as DefaultCanConvert, but also returns the
+// reason if v isn't convertible to t.
+func DefaultCanConvertReason(t reflect.Type, v reflect.Value) (bool, MismatchReason) {
+	return withReason(DefaultCanConvert)(t, v)
+}
+
+// withReason wraps a ConvertibleFunc so that it returns a reason. The reason is
+// determined by comparing the src value (v) to the dst type.
+func withReason(f ConvertibleFunc) ConvertibleReasonFunc {
+	return func(t reflect.Type, v reflect.Value) (bool, MismatchReason) {
+		if f(t, v) {
+			return true, TypeMismatch
+		}
+
+		return false, mismatchReason(t, v)
+	}
+}
+
+// mismatchReason returns the reason the src value (v) isn't convertible to type t.
+func mismatchReason(t reflect.Type, v reflect.Value) MismatchReason {
+	if !v.IsValid() {
+		return NullNotAllowed
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	isInt, unsigned := isIntegerType(t)
+
+	if isInt && isFloatType(v.Type()) {
+		f := v.Float()
+
+		if math.Trunc(f) != f {
+			return Truncation
+		} else if unsigned && f < 0 {
+			return Negative
+		}
+	} else if srcInt, srcUnsigned := isIntegerType(v.Type()); isInt && srcInt && !srcUnsigned {
+		if unsigned && v.Int() < 0 {
+			return Negative
+		}
+	}
+
+	if overflows(t, v) {
+		return Overflow
+	}
+
+	return TypeMismatch
+}
+
+// overflows returns true if the src value (v) is a number which doesn't fit in the
+// number type t. Values which would be truncated or are negative for an unsigned type
+// are not considered an overflow.
+func overflows(t reflect.Type, v reflect.Value) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if !v.IsValid() {
+		return false
+	}
+
+	dst := reflect.New(t).Elem()
+	isInt, unsigned := isIntegerType(t)
+
+	switch {
+	case isInt && isFloatType(v.Type()):
+		f := v.Float()
+
+		if math.Trunc(f) != f || (unsigned && f < 0) {
+			return false
+		} else if unsigned {
+			return f >= math.Exp2(64) || dst.OverflowUint(uint64(f))
+		}
+
+		return f < -math.Exp2(63) || f >= math.Exp2(63) || dst.OverflowInt(int64(f))
+
+	case isInt:
+		if srcInt, srcUnsigned := isIntegerType(v.Type()); srcInt {
+			if srcUnsigned {
+				n := v.Uint()
+
+				if unsigned {
+					return dst.OverflowUint(n)
+				}
+
+				return n > math.MaxInt64 || dst.OverflowInt(int64(n))
+			}
+
+			n := v.Int()
+
+			if unsigned {
+				return n >= 0 && dst.OverflowUint(uint64(n))
+			}
+
+			return dst.OverflowInt(n)
+		}
+
+	case t.Kind() == reflect.Float32 && isFloatType(v.Type()):
+		return dst.OverflowFloat(v.Float())
+	}
+
+	return false
+}
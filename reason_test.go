@@ -0,0 +1,136 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests that each mismatch reason renders its own message.
+func TestFieldMismatch_MessageReasons(t *testing.T) {
+	tests := []struct {
+		srcJson          string
+		reason           schema.MismatchReason
+		message          string
+		messageWithField string
+	}{
+		{
+			srcJson:          `{"Int8":"x"}`,
+			reason:           schema.TypeMismatch,
+			message:          `expected an int8 but it's a string`,
+			messageWithField: `expected "Int8" to be an int8 but it's a string`,
+		},
+		{
+			srcJson:          `{"Int8":1.5}`,
+			reason:           schema.Truncation,
+			message:          `expected an int8 but 1.5 is not a whole number`,
+			messageWithField: `expected "Int8" to be an int8 but 1.5 is not a whole number`,
+		},
+		{
+			srcJson:          `{"Uint8":-2}`,
+			reason:           schema.Negative,
+			message:          `expected a uint8 but -2 is negative`,
+			messageWithField: `expected "Uint8" to be a uint8 but -2 is negative`,
+		},
+		{
+			srcJson:          `{"Int8":200}`,
+			reason:           schema.Overflow,
+			message:          `value 200 overflows int8`,
+			messageWithField: `value 200 of "Int8" overflows int8`,
+		},
+		{
+			srcJson:          `{"Int8":null}`,
+			reason:           schema.NullNotAllowed,
+			message:          `expected an int8 but it's null`,
+			messageWithField: `expected "Int8" to be an int8 but it's null`,
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(&TestStructSized{}, src, nil)
+		require.Len(t, r.MismatchedFields, 1, test.srcJson)
+
+		f := r.MismatchedFields[0]
+		require.Equal(t, test.reason, f.Reason, test.srcJson)
+		require.Equal(t, test.message, f.Message(), test.srcJson)
+		require.Equal(t, test.messageWithField, f.MessageWithField(), test.srcJson)
+	}
+}
+
+// Tests the message of an array with the wrong number of elements.
+func TestFieldMismatch_LengthMismatchMessage(t *testing.T) {
+	src := map[string]interface{}{"Point": []interface{}{1.0, 2.0, 3.0}}
+
+	r, _ := schema.CompareMapToStruct(&TestStructSlice{}, src, nil)
+	require.Len(t, r.MismatchedFields, 1)
+
+	f := r.MismatchedFields[0]
+	require.Equal(t, schema.LengthMismatch, f.Reason)
+	require.Equal(t, `expected a [2]float64 but got 3 elements`, f.Message())
+	require.Equal(t, `expected "Point" to be a [2]float64 but got 3 elements`, f.MessageWithField())
+}
+
+// Tests that reasons are marshaled as their code.
+func TestMismatchReason_MarshalText(t *testing.T) {
+	codes := map[schema.MismatchReason]string{
+		schema.TypeMismatch:   "type_mismatch",
+		schema.Truncation:     "truncation",
+		schema.Negative:       "negative",
+		schema.Overflow:       "overflow",
+		schema.NullNotAllowed: "null_not_allowed",
+		schema.LengthMismatch: "length_mismatch",
+	}
+
+	for reason, code := range codes {
+		require.Equal(t, code, reason.String())
+		require.Equal(t, `"`+code+`"`, toJson(reason))
+	}
+}
+
+// Tests that a ConvertibleReasonFunc is used instead of the ConvertibleFunc, and that
+// its reason is used in the results.
+func TestCompareMapToStruct_ConvertibleReasonFunc(t *testing.T) {
+	src := map[string]interface{}{"Foo": "x", "Bar": 1.0}
+
+	opts := &schema.CompareOpts{
+		ConvertibleFunc: func(t reflect.Type, v reflect.Value) bool {
+			panic("should not be called")
+		},
+		ConvertibleReasonFunc: func(t reflect.Type, v reflect.Value) (bool, schema.MismatchReason) {
+			return t.Kind() != reflect.Int, schema.Overflow
+		},
+	}
+
+	r, _ := schema.CompareMapToStruct(&TestStruct{}, src, opts)
+
+	expected := []mismatch{
+		{Field: "Bar", Expected: "int", Actual: "float64", Reason: schema.Overflow, Value: 1},
+	}
+
+	require.JSONEq(t, toJson(expected), toJson(r.MismatchedFields))
+}
+
+// Tests that the reason is determined automatically for a custom ConvertibleFunc.
+func TestCompareMapToStruct_ConvertibleFuncReason(t *testing.T) {
+	src := map[string]interface{}{"Bar": 2.5, "Foo": nil}
+
+	opts := &schema.CompareOpts{
+		ConvertibleFunc: func(t reflect.Type, v reflect.Value) bool { return false },
+	}
+
+	r, _ := schema.CompareMapToStruct(&TestStruct{}, src, opts)
+
+	expected := []mismatch{
+		{Field: "Foo", Expected: "string", Actual: "null", Reason: schema.NullNotAllowed},
+		{Field: "Bar", Expected: "int", Actual: "float64", Reason: schema.Truncation, Value: 2.5},
+	}
+
+	require.JSONEq(t, toJson(expected), toJson(r.MismatchedFields))
+}
@@ -0,0 +1,113 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type TestStructNode struct {
+	Name     string
+	Children []*TestStructNode
+	Parent   *TestStructNode
+}
+
+// Tests that self-referential structs are decoded the same as json.Unmarshal.
+func TestDecodeMapToStruct_Recursive(t *testing.T) {
+	tests := []string{
+		`{"Name":"a","Children":[],"Parent":null}`,
+		`{"Name":"a","Children":[{"Name":"b","Children":[{"Name":"c","Children":[]}],"Parent":null}],"Parent":{"Name":"p","Children":[]}}`,
+	}
+
+	for _, srcJson := range tests {
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(srcJson), &src)
+
+		expected := TestStructNode{}
+		actual := TestStructNode{}
+
+		require.NoError(t, json.Unmarshal([]byte(srcJson), &expected))
+
+		r, err := schema.DecodeMapToStruct(&actual, src, nil)
+		require.NoError(t, err)
+		require.Nil(t, r.Errors(), srcJson)
+		require.Equal(t, expected, actual, srcJson)
+	}
+}
+
+// Tests that objects and arrays nested deeper than MaxDepth are reported.
+func TestCompareMapToStruct_MaxDepth(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		maxDepth int
+		expected []mismatch
+	}{
+		{
+			srcJson:  `{"Name":"a","Children":[{"Name":"b","Children":[]}]}`,
+			maxDepth: 4,
+			expected: []mismatch{},
+		},
+		{
+			srcJson:  `{"Name":"a","Children":[{"Name":"b","Children":[]}]}`,
+			maxDepth: 3,
+			expected: []mismatch{
+				{Field: "Children", Expected: "[]*TestStructNode", Actual: "[]interface {}", Path: []string{"Children", "0"}, Reason: schema.DepthExceeded},
+			},
+		},
+		{
+			srcJson:  `{"Name":"a","Parent":{"Name":"b","Parent":{"Name":"c"}}}`,
+			maxDepth: 2,
+			expected: []mismatch{
+				{Field: "Parent", Expected: "*TestStructNode", Actual: "map[string]interface {}", Path: []string{"Parent"}, Reason: schema.DepthExceeded},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		opts := &schema.CompareOpts{MaxDepth: test.maxDepth}
+
+		r, err := schema.CompareMapToStruct(&TestStructNode{}, src, opts)
+		require.NoError(t, err)
+		require.JSONEq(t, toJson(test.expected), toJson(r.MismatchedFields), test.srcJson)
+	}
+}
+
+// Tests that a very deeply nested src is stopped at DefaultMaxDepth without using
+// much memory.
+func TestCompareMapToStruct_DefaultMaxDepth(t *testing.T) {
+	src := map[string]interface{}{"Name": "leaf"}
+
+	for i := 0; i < 10000; i++ {
+		src = map[string]interface{}{"Parent": src}
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	r, err := schema.CompareMapToStruct(&TestStructNode{}, src, nil)
+
+	runtime.ReadMemStats(&after)
+
+	require.NoError(t, err)
+	require.Len(t, r.MismatchedFields, 1)
+	require.Equal(t, schema.DepthExceeded, r.MismatchedFields[0].Reason)
+	require.Len(t, r.MismatchedFields[0].Path, schema.DefaultMaxDepth-1)
+	require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+}
+
+// Tests that a src which contains itself doesn't recurse forever.
+func TestCompareMapToStruct_CyclicSrc(t *testing.T) {
+	src := map[string]interface{}{"Name": "a"}
+	src["Parent"] = src
+
+	r, err := schema.CompareMapToStruct(&TestStructNode{}, src, &schema.CompareOpts{MaxDepth: 5})
+	require.NoError(t, err)
+	require.Len(t, r.MismatchedFields, 1)
+	require.Equal(t, "exceeds the maximum depth", r.MismatchedFields[0].Message())
+}
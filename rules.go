@@ -0,0 +1,331 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// rule is a validation rule from a field's schema tag, e.g. `schema:"min=1"`.
+type rule struct {
+	// name is the name of the rule, e.g. "min".
+	name string
+
+	// param is the rule's parameter as it appears in the tag.
+	param string
+
+	num  float64
+	re   *regexp.Regexp
+	enum []string
+}
+
+// ruleReasons maps each rule to the reason used when the rule is violated.
+var ruleReasons = map[string]MismatchReason{
+	"min":      OutOfRange,
+	"max":      OutOfRange,
+	"len":      InvalidLength,
+	"minlen":   InvalidLength,
+	"maxlen":   InvalidLength,
+	"minitems": InvalidLength,
+	"maxitems": InvalidLength,
+	"pattern":  PatternMismatch,
+	"enum":     NotInEnum,
+}
+
+// schemaTag is a parsed schema tag.
+type schemaTag struct {
+	rules []rule
+
+	// required and optional are set if the tag has the "required" or "optional" flag.
+	required bool
+	optional bool
+
+	// nullable and notNull are set if the tag has the "nullable" or "notnull" flag.
+	nullable bool
+	notNull  bool
+}
+
+/*
+parseSchemaTag parses a schema tag. The tag is a comma separated list of flags and
+rules. The flags are:
+
+	required  the field must be in src
+	optional  the field doesn't need to be in src
+	nullable  the field can be null, even if it's not a pointer
+	notnull   the field can't be null, even if it's a pointer
+
+Rules have the form name=param:
+
+	min=N, max=N           the number must be >= N or <= N
+	len=N                  the string, slice or map must have a length of N
+	minlen=N, maxlen=N     the string, slice or map must have a length >= N or <= N
+	minitems=N, maxitems=N the slice or map must have >= N or <= N items
+	pattern=REGEX          the string must match the regular expression
+	enum=A|B|C             the value must be one of the options
+
+Since a regular expression may contain commas, pattern must be the last rule and
+uses the rest of the tag.
+*/
+func parseSchemaTag(tag string) (schemaTag, error) {
+	var st schemaTag
+
+	for tag != "" {
+		var part string
+
+		if strings.HasPrefix(tag, "pattern=") {
+			part, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i != -1 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		switch part {
+		case "":
+			continue
+		case "required":
+			st.required = true
+			continue
+		case "optional":
+			st.optional = true
+			continue
+		case "nullable":
+			st.nullable = true
+			continue
+		case "notnull":
+			st.notNull = true
+			continue
+		}
+
+		name, param, ok := strings.Cut(part, "=")
+
+		if _, known := ruleReasons[name]; !known {
+			return st, fmt.Errorf("unknown rule %q", name)
+		} else if !ok {
+			return st, fmt.Errorf("rule %q is missing a parameter", name)
+		}
+
+		r := rule{name: name, param: param}
+
+		switch name {
+		case "pattern":
+			re, err := regexp.Compile(param)
+
+			if err != nil {
+				return st, err
+			}
+
+			r.re = re
+
+		case "enum":
+			r.enum = strings.Split(param, "|")
+
+		default:
+			n, err := strconv.ParseFloat(param, 64)
+
+			if err != nil {
+				return st, fmt.Errorf("rule %q: %w", name, errors.Unwrap(err))
+			} else if name != "min" && name != "max" && (n < 0 || math.Trunc(n) != n) {
+				return st, fmt.Errorf("rule %q must be a non-negative integer", name)
+			}
+
+			r.num = n
+		}
+
+		st.rules = append(st.rules, r)
+	}
+
+	if st.required && st.optional {
+		return st, errors.New("a field can't be both required and optional")
+	} else if st.nullable && st.notNull {
+		return st, errors.New("a field can't be both nullable and notnull")
+	}
+
+	return st, nil
+}
+
+// check returns false if the src value (v) violates the rule. Rules which don't
+// apply to v (e.g. min on a string) are ignored. t is the type of the dst field,
+// which is used to check strings which are coerced to numbers.
+func (r rule) check(t reflect.Type, v reflect.Value) bool {
+	switch r.name {
+	case "min", "max":
+		n, ok := numberValue(t, v)
+
+		if !ok {
+			return true
+		} else if r.name == "min" {
+			return n >= r.num
+		}
+
+		return n <= r.num
+
+	case "len", "minlen", "maxlen", "minitems", "maxitems":
+		n, ok := lengthValue(v)
+
+		if !ok || ((r.name == "minitems" || r.name == "maxitems") && v.Kind() == reflect.String) {
+			return true
+		}
+
+		switch r.name {
+		case "len":
+			return float64(n) == r.num
+		case "minlen", "minitems":
+			return float64(n) >= r.num
+		}
+
+		return float64(n) <= r.num
+
+	case "pattern":
+		if v.Kind() != reflect.String {
+			return true
+		}
+
+		return r.re.MatchString(v.String())
+
+	case "enum":
+		s, ok := stringValue(v)
+
+		if !ok {
+			return true
+		}
+
+		for _, opt := range r.enum {
+			if s == opt {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// message returns a description of the rule, e.g. "must be at least 1".
+func (r rule) message() string {
+	switch r.name {
+	case "min":
+		return "must be at least " + r.param
+	case "max":
+		return "must be at most " + r.param
+	case "len":
+		return "must have a length of " + r.param
+	case "minlen":
+		return "must have a length of at least " + r.param
+	case "maxlen":
+		return "must have a length of at most " + r.param
+	case "minitems":
+		return "must have at least " + r.param + " items"
+	case "maxitems":
+		return "must have at most " + r.param + " items"
+	case "pattern":
+		return "must match the pattern " + r.param
+	case "enum":
+		return "must be one of: " + strings.Join(r.enum, ", ")
+	}
+
+	return ""
+}
+
+// isRequired returns whether the field must be in src.
+func (f *fieldInfo) isRequired(policy RequiredPolicy) bool {
+	switch {
+	case f.required:
+		return true
+	case f.optional, f.info.wrapper == optionalWrapper, policy&RequireAll == 0:
+		return false
+	case policy&OmitEmptyIsOptional != 0 && f.omitEmpty:
+		return false
+	case policy&PointerIsOptional != 0 && f.info.typ.Kind() == reflect.Ptr:
+		return false
+	}
+
+	return true
+}
+
+// checkRules checks the src value (v) against each of the field's rules and adds any
+// violations to the results. Null values are never checked.
+func checkRules(f *fieldInfo, v reflect.Value, path []string, results *CompareResults) {
+	if !v.IsValid() {
+		return
+	}
+
+	t := f.info.typ
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, r := range f.rules {
+		if r.check(t, v) {
+			continue
+		}
+
+		results.InvalidFields = append(results.InvalidFields, FieldInvalid{
+			Field:  f.name,
+			Path:   path,
+			Rule:   r.name,
+			Param:  r.param,
+			Reason: ruleReasons[r.name],
+			Value:  v.Interface(),
+			rule:   r,
+		})
+	}
+}
+
+// numberValue returns the src value (v) as a float64. Strings are converted if they
+// can be coerced to the dst type t.
+func numberValue(t reflect.Type, v reflect.Value) (float64, bool) {
+	if v.Kind() == reflect.String {
+		coerced, ok := coerceValue(t, v)
+
+		if !ok || coerced.Kind() == reflect.String || coerced.Kind() == reflect.Bool {
+			return 0, false
+		}
+
+		v = coerced
+	}
+
+	if isFloatType(v.Type()) {
+		return v.Float(), true
+	} else if isInt, unsigned := isIntegerType(v.Type()); isInt && unsigned {
+		return float64(v.Uint()), true
+	} else if isInt {
+		return float64(v.Int()), true
+	}
+
+	return 0, false
+}
+
+// lengthValue returns the length of a string (in runes), slice, array or map.
+func lengthValue(v reflect.Value) (int, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), true
+	}
+
+	return 0, false
+}
+
+// stringValue returns the src value (v) as a string if it's a string or number.
+func stringValue(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.String {
+		return v.String(), true
+	} else if isFloatType(v.Type()) {
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	} else if isInt, unsigned := isIntegerType(v.Type()); isInt && unsigned {
+		return strconv.FormatUint(v.Uint(), 10), true
+	} else if isInt {
+		return strconv.FormatInt(v.Int(), 10), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,306 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type invalid struct {
+	Field  string
+	Path   []string
+	Rule   string
+	Param  string
+	Reason schema.MismatchReason
+	Value  interface{}
+}
+
+type TestStructRules struct {
+	Age     int               `schema:"min=1,max=120"`
+	Name    string            `schema:"minlen=2,maxlen=4"`
+	Country string            `schema:"len=2,pattern=^[A-Z]{2}$"`
+	Color   *string           `schema:"enum=red|green|blue"`
+	Tags    []string          `schema:"minitems=1,maxitems=2"`
+	Labels  map[string]string `schema:"maxitems=1"`
+	Level   int               `schema:"enum=1|2|3"`
+	Nested  struct {
+		Score float64 `schema:"max=1.5"`
+	}
+	Items []struct {
+		Code string `schema:"pattern=^[a-z]+,[0-9]+$"`
+	}
+}
+
+// Tests that CompareMapToStruct identifies fields which violate the rules in the
+// schema tag.
+func TestCompareMapToStruct_InvalidFields(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		expected []invalid
+	}{
+		{
+			srcJson:  `{}`,
+			expected: []invalid{},
+		},
+		{
+			srcJson:  `{"Age":1,"Name":"ab","Country":"US","Color":"red","Tags":["a"],"Labels":{},"Level":3,"Nested":{"Score":1.5},"Items":[{"Code":"ab,12"}]}`,
+			expected: []invalid{},
+		},
+		{
+			srcJson:  `{"Age":120,"Name":"ábçd","Color":null}`,
+			expected: []invalid{},
+		},
+		{
+			srcJson: `{"Age":0,"Name":"a","Country":"usa","Color":"pink","Tags":[],"Labels":{"a":"","b":""},"Level":4}`,
+			expected: []invalid{
+				{Field: "Age", Rule: "min", Param: "1", Reason: schema.OutOfRange, Value: 0},
+				{Field: "Name", Rule: "minlen", Param: "2", Reason: schema.InvalidLength, Value: "a"},
+				{Field: "Country", Rule: "len", Param: "2", Reason: schema.InvalidLength, Value: "usa"},
+				{Field: "Country", Rule: "pattern", Param: "^[A-Z]{2}$", Reason: schema.PatternMismatch, Value: "usa"},
+				{Field: "Color", Rule: "enum", Param: "red|green|blue", Reason: schema.NotInEnum, Value: "pink"},
+				{Field: "Tags", Rule: "minitems", Param: "1", Reason: schema.InvalidLength, Value: []interface{}{}},
+				{Field: "Labels", Rule: "maxitems", Param: "1", Reason: schema.InvalidLength, Value: map[string]interface{}{"a": "", "b": ""}},
+				{Field: "Level", Rule: "enum", Param: "1|2|3", Reason: schema.NotInEnum, Value: 4},
+			},
+		},
+		{
+			srcJson: `{"Age":121,"Name":"abcde","Tags":["a","b","c"],"Nested":{"Score":2},"Items":[{"Code":"ab,12"},{"Code":"ab"}]}`,
+			expected: []invalid{
+				{Field: "Age", Rule: "max", Param: "120", Reason: schema.OutOfRange, Value: 121},
+				{Field: "Name", Rule: "maxlen", Param: "4", Reason: schema.InvalidLength, Value: "abcde"},
+				{Field: "Tags", Rule: "maxitems", Param: "2", Reason: schema.InvalidLength, Value: []interface{}{"a", "b", "c"}},
+				{Field: "Score", Path: []string{"Nested"}, Rule: "max", Param: "1.5", Reason: schema.OutOfRange, Value: 2},
+				{Field: "Code", Path: []string{"Items", "1"}, Rule: "pattern", Param: "^[a-z]+,[0-9]+$", Reason: schema.PatternMismatch, Value: "ab"},
+			},
+		},
+		{
+			// Rules are not checked if there is a type mismatch.
+			srcJson:  `{"Age":"old","Name":5}`,
+			expected: []invalid{},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, err := schema.CompareMapToStruct(&TestStructRules{}, src, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, toJson(test.expected), toJson(r.InvalidFields), test.srcJson)
+	}
+}
+
+// Tests that rules are checked against the coerced value when using CoercingCanConvert.
+func TestCompareMapToStruct_InvalidFieldsCoerced(t *testing.T) {
+	src := map[string]interface{}{"Age": "200"}
+	opts := &schema.CompareOpts{ConvertibleFunc: schema.CoercingCanConvert}
+
+	r, _ := schema.CompareMapToStruct(&TestStructRules{}, src, opts)
+
+	expected := []invalid{
+		{Field: "Age", Rule: "max", Param: "120", Reason: schema.OutOfRange, Value: "200"},
+	}
+
+	require.JSONEq(t, toJson(expected), toJson(r.InvalidFields))
+}
+
+// Tests the messages for rule violations and that they're included in Errors.
+func TestFieldInvalid_Message(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Age":0,"Name":"abcde","Color":"pink","Tags":[],"Nested":{"Score":2}}`), &src)
+
+	r, _ := schema.CompareMapToStruct(&TestStructRules{}, src, nil)
+
+	require.Equal(t, `"Age" must be at least 1`, r.InvalidFields[0].String())
+	require.Equal(t, schema.MismatchError{
+		"Age":   "must be at least 1",
+		"Name":  "must have a length of at most 4",
+		"Color": "must be one of: red, green, blue",
+		"Tags":  "must have at least 1 items",
+		"Nested": map[string]interface{}{
+			"Score": "must be at most 1.5",
+		},
+	}, r.Errors())
+}
+
+type TestStructRulesContainer struct {
+	Items []struct {
+		Name string
+	} `schema:"minitems=2"`
+}
+
+// Tests that a field's own rule violation and the errors of its elements are both
+// reported by Errors.
+func TestCompareResults_ErrorsContainerRules(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		opts     *schema.CompareOpts
+		expected schema.MismatchError
+		message  string
+	}{
+		{
+			srcJson: `{"Items":[{"Name":"a","extra":1}]}`,
+			opts:    &schema.CompareOpts{DisallowUnknownFields: true},
+			expected: schema.MismatchError{
+				"Items": map[string]interface{}{
+					"":  "must have at least 2 items",
+					"0": map[string]interface{}{"extra": "unknown field"},
+				},
+			},
+			message: "Items: must have at least 2 items, Items.0.extra: unknown field",
+		},
+		{
+			srcJson: `{"Items":[{}]}`,
+			opts:    &schema.CompareOpts{Required: schema.RequireAll},
+			expected: schema.MismatchError{
+				"Items": map[string]interface{}{
+					"":  "must have at least 2 items",
+					"0": map[string]interface{}{"Name": "this field is required"},
+				},
+			},
+			message: "Items: must have at least 2 items, Items.0.Name: this field is required",
+		},
+		{
+			srcJson: `{"Items":[{"Name":3}]}`,
+			expected: schema.MismatchError{
+				"Items": map[string]interface{}{
+					"":  "must have at least 2 items",
+					"0": map[string]interface{}{"Name": "expected a string but it's a float64"},
+				},
+			},
+			message: "Items: must have at least 2 items, Items.0.Name: expected a string but it's a float64",
+		},
+	}
+
+	for _, test := range tests {
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(&TestStructRulesContainer{}, src, test.opts)
+		err := r.Errors()
+
+		require.Equal(t, test.expected, err, test.srcJson)
+		require.Equal(t, test.message, err.Error(), test.srcJson)
+	}
+}
+
+// Tests that invalid schema tags return an error.
+func TestCompile_InvalidTag(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			A int `schema:"min"`
+		}{},
+		&struct {
+			A int `schema:"min=abc"`
+		}{},
+		&struct {
+			A string `schema:"len=-1"`
+		}{},
+		&struct {
+			A string `schema:"bogus=1"`
+		}{},
+		&struct {
+			A string `schema:"pattern=(["`
+		}{},
+	}
+
+	for _, dst := range tests {
+		_, err := schema.Compile(dst, nil)
+		require.True(t, errors.Is(err, schema.ErrInvalidTag), err)
+
+		_, err = schema.CompareMapToStruct(dst, map[string]interface{}{}, nil)
+		require.True(t, errors.Is(err, schema.ErrInvalidTag), err)
+	}
+}
+
+type TestStructRequired struct {
+	Tagged    string `schema:"required"`
+	Optional  string `schema:"optional"`
+	Plain     string
+	OmitEmpty string `json:",omitempty"`
+	Ptr       *string
+	Nested    struct {
+		Tagged string `schema:"required"`
+		Plain  string
+	}
+}
+
+// Tests that MissingRequiredFields only includes the required fields depending on the
+// tags and the required policy.
+func TestCompareMapToStruct_MissingRequiredFields(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		policy   schema.RequiredPolicy
+		expected []missing
+	}{
+		{
+			srcJson:  `{}`,
+			expected: []missing{{Field: "Tagged"}},
+		},
+		{
+			srcJson:  `{"Tagged":"","Nested":{}}`,
+			expected: []missing{{Field: "Tagged", Path: []string{"Nested"}}},
+		},
+		{
+			srcJson:  `{}`,
+			policy:   schema.RequireAll,
+			expected: []missing{{Field: "Tagged"}, {Field: "Plain"}, {Field: "OmitEmpty"}, {Field: "Ptr"}, {Field: "Nested"}},
+		},
+		{
+			srcJson:  `{}`,
+			policy:   schema.RequireAll | schema.OmitEmptyIsOptional,
+			expected: []missing{{Field: "Tagged"}, {Field: "Plain"}, {Field: "Ptr"}, {Field: "Nested"}},
+		},
+		{
+			srcJson:  `{"Nested":{}}`,
+			policy:   schema.RequireAll | schema.OmitEmptyIsOptional | schema.PointerIsOptional,
+			expected: []missing{{Field: "Tagged"}, {Field: "Plain"}, {Field: "Tagged", Path: []string{"Nested"}}, {Field: "Plain", Path: []string{"Nested"}}},
+		},
+		{
+			// Pointer and omitempty flags have no effect without RequireAll.
+			srcJson:  `{}`,
+			policy:   schema.OmitEmptyIsOptional | schema.PointerIsOptional,
+			expected: []missing{{Field: "Tagged"}},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(&TestStructRequired{}, src, &schema.CompareOpts{Required: test.policy})
+		require.JSONEq(t, toJson(test.expected), toJson(r.MissingRequiredFields), test.srcJson)
+	}
+}
+
+// Tests that missing required fields are included in Errors, and optional ones aren't.
+func TestCompareResults_ErrorsMissingRequired(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Nested":{}}`), &src)
+
+	r, _ := schema.CompareMapToStruct(&TestStructRequired{}, src, nil)
+	require.Equal(t, schema.MismatchError{
+		"Tagged": "this field is required",
+		"Nested": map[string]interface{}{
+			"Tagged": "this field is required",
+		},
+	}, r.Errors())
+	require.Len(t, r.MissingFields, 7)
+
+	r, _ = schema.CompareMapToStruct(&TestStruct{}, src, nil)
+	require.Nil(t, r.Errors())
+}
+
+// Tests that a field can't be both required and optional.
+func TestCompile_RequiredAndOptional(t *testing.T) {
+	_, err := schema.Compile(&struct {
+		A string `schema:"required,optional"`
+	}{}, nil)
+
+	require.True(t, errors.Is(err, schema.ErrInvalidTag))
+}
@@ -1,10 +1,15 @@
 package schema
 
 import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
-	"strings"
+	"sort"
+	"strconv"
 )
 
 // CompareResults contains the results of CompareMapToStruct.
@@ -14,49 +19,400 @@ type CompareResults struct {
 
 	// MissingFields is a list of JSON field names which were not in src.
 	MissingFields []FieldMissing
+
+	// UnknownFields is a list of field names which were in src but not in dst.
+	UnknownFields []FieldUnknown
+
+	// CoercedFields is a list of fields which were converted between a string and
+	// a number or bool (see CoercingCanConvert).
+	CoercedFields []FieldCoerced
+
+	// InvalidFields is a list of fields which have the correct type but violate one
+	// of the rules in their schema tag.
+	InvalidFields []FieldInvalid
+
+	// MissingRequiredFields is the list of fields in MissingFields which are required
+	// (see CompareOpts.Required).
+	MissingRequiredFields []FieldMissing
+
+	// NullFields is a list of fields which were explicitly set to null in src. This
+	// can be used to tell "clear this value" apart from "leave it unchanged" (which
+	// is a missing field).
+	NullFields []FieldNull
+
+	// Warnings is a list of keys in src which were matched to a field when ignoring
+	// case (see CompareOpts.CaseInsensitive).
+	Warnings []FieldWarning
+
+	// opts are the options that were used for the comparison.
+	opts *CompareOpts
 }
 
-// Errors returns a MismatchError containing the type errors. If there were no
-// type errors, returns nil.
+// Errors returns a MismatchError containing the type errors, rule violations and
+// missing required fields. If the results were created with DisallowUnknownFields,
+// unknown fields are included as well. If there were no errors, returns nil.
 func (cr *CompareResults) Errors() error {
-	if len(cr.MismatchedFields) == 0 {
+	disallowUnknown := cr.opts != nil && cr.opts.DisallowUnknownFields
+
+	if len(cr.MismatchedFields) == 0 && len(cr.InvalidFields) == 0 && len(cr.MissingRequiredFields) == 0 &&
+		(!disallowUnknown || len(cr.UnknownFields) == 0) {
 		return nil
 	}
 
 	m := make(map[string]interface{})
 
 	for _, f := range cr.MismatchedFields {
-		cursor := m
-
-		// Create additional maps for nested fields so they are reported using
-		// the same schema. For example, `address.city` would be reported as
-		// {"address": {"city": "..."}}
-		for _, path := range f.Path {
-			if _, ok := cursor[path]; !ok {
-				cursor[path] = make(map[string]interface{})
-			}
+		cr.setError(m, f.Field, f.Path, f.Message())
+	}
 
-			cursor = cursor[path].(map[string]interface{})
-		}
+	for _, f := range cr.InvalidFields {
+		cr.setError(m, f.Field, f.Path, f.Message())
+	}
 
-		cursor[f.Field] = f.Message()
+	for _, f := range cr.MissingRequiredFields {
+		cr.setError(m, f.Field, f.Path, f.Message())
+	}
+
+	if disallowUnknown {
+		for _, f := range cr.UnknownFields {
+			cr.setError(m, f.Field, f.Path, f.Message())
+		}
 	}
 
 	return MismatchError(m)
 }
 
+// Err returns a *ValidationError containing the same errors as Errors, but as typed
+// errors which can be inspected with errors.As. If there were no errors, returns nil.
+func (cr *CompareResults) Err() error {
+	var errs []fieldError
+
+	for _, f := range cr.MismatchedFields {
+		errs = append(errs, fieldError{f.Field, f.Path, &TypeMismatchError{f}})
+	}
+
+	for _, f := range cr.InvalidFields {
+		errs = append(errs, fieldError{f.Field, f.Path, &RuleViolationError{f}})
+	}
+
+	for _, f := range cr.MissingRequiredFields {
+		errs = append(errs, fieldError{f.Field, f.Path, &MissingFieldError{f}})
+	}
+
+	if cr.opts != nil && cr.opts.DisallowUnknownFields {
+		for _, f := range cr.UnknownFields {
+			errs = append(errs, fieldError{f.Field, f.Path, &UnknownFieldError{f}})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if cr.opts != nil && cr.opts.SortByPath {
+		sort.SliceStable(errs, func(i, j int) bool {
+			return lessFieldPath(errs[i].field, errs[i].path, errs[j].field, errs[j].path)
+		})
+	}
+
+	validationErr := &ValidationError{Errors: make([]error, len(errs))}
+
+	for i := range errs {
+		validationErr.Errors[i] = errs[i].err
+	}
+
+	return validationErr
+}
+
+// fieldError is an error for a field, along with the field's path.
+type fieldError struct {
+	field string
+	path  []string
+	err   error
+}
+
+// setError sets the error message for a field in m, using the path style from the
+// options.
+func (cr *CompareResults) setError(m map[string]interface{}, field string, path []string, msg string) {
+	if cr.opts != nil && cr.opts.PathStyle == PointerPath {
+		m[JSONPointer(field, path)] = msg
+	} else {
+		setNestedError(m, field, path, msg)
+	}
+}
+
+// finish applies the options which change how the results are reported.
+func (cr *CompareResults) finish() {
+	cr.setPathStyle(cr.opts.PathStyle)
+
+	if cr.opts.SortByPath {
+		cr.sortByPath()
+	}
+}
+
+// sortByPath sorts each list of fields by the path of the field.
+func (cr *CompareResults) sortByPath() {
+	sort.SliceStable(cr.MismatchedFields, func(i, j int) bool {
+		a, b := cr.MismatchedFields[i], cr.MismatchedFields[j]
+		return lessFieldPath(a.Field, a.Path, b.Field, b.Path)
+	})
+	sort.SliceStable(cr.MissingFields, func(i, j int) bool {
+		a, b := cr.MissingFields[i], cr.MissingFields[j]
+		return lessFieldPath(a.Field, a.Path, b.Field, b.Path)
+	})
+	sort.SliceStable(cr.UnknownFields, func(i, j int) bool {
+		a, b := cr.UnknownFields[i], cr.UnknownFields[j]
+		return lessFieldPath(a.Field, a.Path, b.Field, b.Path)
+	})
+	sort.SliceStable(cr.CoercedFields, func(i, j int) bool {
+		a, b := cr.CoercedFields[i], cr.CoercedFields[j]
+		return lessFieldPath(a.Field, a.Path, b.Field, b.Path)
+	})
+	sort.SliceStable(cr.InvalidFields, func(i, j int) bool {
+		a, b := cr.InvalidFields[i], cr.InvalidFields[j]
+		return lessFieldPath(a.Field, a.Path, b.Field, b.Path)
+	})
+	sort.SliceStable(cr.MissingRequiredFields, func(i, j int) bool {
+		a, b := cr.MissingRequiredFields[i], cr.MissingRequiredFields[j]
+		return lessFieldPath(a.Field, a.Path, b.Field, b.Path)
+	})
+	sort.SliceStable(cr.NullFields, func(i, j int) bool {
+		a, b := cr.NullFields[i], cr.NullFields[j]
+		return lessFieldPath(a.Field, a.Path, b.Field, b.Path)
+	})
+	sort.SliceStable(cr.Warnings, func(i, j int) bool {
+		a, b := cr.Warnings[i], cr.Warnings[j]
+		return lessFieldPath(a.Field, a.Path, b.Field, b.Path)
+	})
+}
+
+// setPathStyle sets the path style used by the String methods of each field.
+func (cr *CompareResults) setPathStyle(style PathStyle) {
+	for i := range cr.MismatchedFields {
+		cr.MismatchedFields[i].style = style
+	}
+	for i := range cr.MissingFields {
+		cr.MissingFields[i].style = style
+	}
+	for i := range cr.UnknownFields {
+		cr.UnknownFields[i].style = style
+	}
+	for i := range cr.CoercedFields {
+		cr.CoercedFields[i].style = style
+	}
+	for i := range cr.InvalidFields {
+		cr.InvalidFields[i].style = style
+	}
+	for i := range cr.MissingRequiredFields {
+		cr.MissingRequiredFields[i].style = style
+	}
+	for i := range cr.NullFields {
+		cr.NullFields[i].style = style
+	}
+	for i := range cr.Warnings {
+		cr.Warnings[i].style = style
+	}
+}
+
+// setNestedError sets the error message for a field in m. Additional maps are created
+// for nested fields so they are reported using the same schema. For example,
+// `address.city` would be reported as {"address": {"city": "..."}}
+//
+// A field can have an error of its own as well as errors in its nested fields or
+// elements, e.g. a slice which violates a minitems rule and has an element with a
+// type mismatch. Its own error is then stored under the empty key:
+// {"items": {"": "...", "0": {"name": "..."}}}
+func setNestedError(m map[string]interface{}, field string, path []string, msg string) {
+	cursor := m
+
+	for _, p := range path {
+		switch next := cursor[p].(type) {
+		case map[string]interface{}:
+			cursor = next
+		case string:
+			nested := map[string]interface{}{"": next}
+			cursor[p] = nested
+			cursor = nested
+		default:
+			nested := make(map[string]interface{})
+			cursor[p] = nested
+			cursor = nested
+		}
+	}
+
+	if nested, ok := cursor[field].(map[string]interface{}); ok {
+		nested[""] = msg
+	} else {
+		cursor[field] = msg
+	}
+}
+
 type FieldMissing struct {
 	// Field is the JSON name of the field.
 	Field string
 
 	// Path is the full path to the field.
 	Path []string
+
+	style PathStyle
+}
+
+// Message returns the missing field error as a string.
+func (f FieldMissing) Message() string {
+	return "this field is required"
 }
 
 // String returns the field name with its path.
 // e.g: "Cat.Foo"
 func (f FieldMissing) String() string {
-	return FieldNameWithPath(f.Field, f.Path)
+	return fieldNameWithPath(f.style, f.Field, f.Path)
+}
+
+// Pointer returns the field name with its path as a JSON pointer.
+// e.g: "/Cat/Foo"
+func (f FieldMissing) Pointer() string {
+	return JSONPointer(f.Field, f.Path)
+}
+
+// FieldNull represents a field which was set to null in src.
+type FieldNull struct {
+	// Field is the JSON name of the field.
+	Field string
+
+	// Path is the full path to the field.
+	Path []string
+
+	style PathStyle
+}
+
+// String returns the field name with its path.
+// e.g: "Cat.Foo"
+func (f FieldNull) String() string {
+	return fieldNameWithPath(f.style, f.Field, f.Path)
+}
+
+// Pointer returns the field name with its path as a JSON pointer.
+// e.g: "/Cat/Foo"
+func (f FieldNull) Pointer() string {
+	return JSONPointer(f.Field, f.Path)
+}
+
+// FieldUnknown represents a field in src which does not exist in dst.
+type FieldUnknown struct {
+	// Field is the name of the field in src.
+	Field string
+
+	// Path is the full path to the field.
+	Path []string
+
+	style PathStyle
+}
+
+// Message returns the unknown field error as a string.
+func (f FieldUnknown) Message() string {
+	return "unknown field"
+}
+
+// String returns the field name with its path.
+// e.g: "Cat.Foo"
+func (f FieldUnknown) String() string {
+	return fieldNameWithPath(f.style, f.Field, f.Path)
+}
+
+// Pointer returns the field name with its path as a JSON pointer.
+// e.g: "/Cat/Foo"
+func (f FieldUnknown) Pointer() string {
+	return JSONPointer(f.Field, f.Path)
+}
+
+// FieldCoerced represents a src field which was coerced to the type of the dst field,
+// such as "26" -> int.
+type FieldCoerced struct {
+	// Field is the JSON name of the field.
+	Field string
+
+	// Expected is the type of the dst field.
+	Expected string
+
+	// Actual is the type of the src field.
+	Actual string
+
+	// Path is the full path to the field.
+	Path []string
+
+	style PathStyle
+}
+
+// Message returns the coercion as a string.
+// e.g: "converted a string to an int"
+func (f FieldCoerced) Message() string {
+	return fmt.Sprintf(
+		`converted %s to %s`,
+		TypeNameWithArticle(f.Actual),
+		TypeNameWithArticle(f.Expected),
+	)
+}
+
+// String returns the coercion as a string, including the field name with its path.
+// e.g: "converted Cat.Foo from a string to an int"
+func (f FieldCoerced) String() string {
+	return fmt.Sprintf(
+		`converted "%s" from %s to %s`,
+		fieldNameWithPath(f.style, f.Field, f.Path),
+		TypeNameWithArticle(f.Actual),
+		TypeNameWithArticle(f.Expected),
+	)
+}
+
+// Pointer returns the field name with its path as a JSON pointer.
+// e.g: "/Cat/Foo"
+func (f FieldCoerced) Pointer() string {
+	return JSONPointer(f.Field, f.Path)
+}
+
+// FieldInvalid represents a src field which has the correct type but violates one of
+// the rules in the dst field's schema tag.
+type FieldInvalid struct {
+	// Field is the JSON name of the field.
+	Field string
+
+	// Path is the full path to the field.
+	Path []string
+
+	// Rule is the name of the rule which was violated, e.g. "min".
+	Rule string
+
+	// Param is the rule's parameter, e.g. "1" for `schema:"min=1"`.
+	Param string
+
+	// Reason is the reason code for the rule (OutOfRange, InvalidLength,
+	// PatternMismatch or NotInEnum).
+	Reason MismatchReason
+
+	// Value is the src value.
+	Value interface{}
+
+	rule  rule
+	style PathStyle
+}
+
+// Message returns the rule violation as a string.
+// e.g: "must be at least 1"
+func (f FieldInvalid) Message() string {
+	return f.rule.message()
+}
+
+// String returns the rule violation as a string, including the field name with its
+// path.
+// e.g: "Cat.Age must be at least 1"
+func (f FieldInvalid) String() string {
+	return fmt.Sprintf(`"%s" %s`, fieldNameWithPath(f.style, f.Field, f.Path), f.Message())
+}
+
+// Pointer returns the field name with its path as a JSON pointer.
+// e.g: "/Cat/Foo"
+func (f FieldInvalid) Pointer() string {
+	return JSONPointer(f.Field, f.Path)
 }
 
 // FieldMismatch represents a type mismatch between a struct field and a map field.
@@ -72,11 +428,40 @@ type FieldMismatch struct {
 
 	// Path is the full path to the field.
 	Path []string
+
+	// Reason is why the src field can't be converted.
+	Reason MismatchReason
+
+	// Value is the src value. It's only set when the type is compatible but the
+	// value itself is the problem (Truncation, Negative, Overflow, ParseError). For
+	// LengthMismatch it's the number of elements in the src value.
+	Value interface{} `json:",omitempty"`
+
+	// Detail is the error returned when unmarshaling the src value (ParseError).
+	Detail string `json:",omitempty"`
+
+	style PathStyle
 }
 
-// Message returns the field mismatch error as a string.
+// Message returns the field mismatch error as a string. The message depends on
+// the Reason.
 // e.g: "expected an int but it's a string"
 func (f FieldMismatch) Message() string {
+	switch f.Reason {
+	case Truncation:
+		return fmt.Sprintf(`expected %s but %v is not a whole number`, TypeNameWithArticle(f.Expected), f.Value)
+	case Negative:
+		return fmt.Sprintf(`expected %s but %v is negative`, TypeNameWithArticle(f.Expected), f.Value)
+	case Overflow:
+		return fmt.Sprintf(`value %v overflows %s`, f.Value, f.Expected)
+	case ParseError:
+		return fmt.Sprintf(`not a valid %s: %s`, f.Expected, f.Detail)
+	case DepthExceeded:
+		return `exceeds the maximum depth`
+	case LengthMismatch:
+		return fmt.Sprintf(`expected %s but got %v elements`, TypeNameWithArticle(f.Expected), f.Value)
+	}
+
 	return fmt.Sprintf(
 		`expected %s but it's %s`,
 		TypeNameWithArticle(f.Expected),
@@ -88,9 +473,26 @@ func (f FieldMismatch) Message() string {
 // with its path in the message.
 // e.g: "expected Cat.Foo to be an int but it's a string"
 func (f FieldMismatch) MessageWithField() string {
+	field := fieldNameWithPath(f.style, f.Field, f.Path)
+
+	switch f.Reason {
+	case Truncation:
+		return fmt.Sprintf(`expected "%s" to be %s but %v is not a whole number`, field, TypeNameWithArticle(f.Expected), f.Value)
+	case Negative:
+		return fmt.Sprintf(`expected "%s" to be %s but %v is negative`, field, TypeNameWithArticle(f.Expected), f.Value)
+	case Overflow:
+		return fmt.Sprintf(`value %v of "%s" overflows %s`, f.Value, field, f.Expected)
+	case ParseError:
+		return fmt.Sprintf(`"%s" is not a valid %s: %s`, field, f.Expected, f.Detail)
+	case DepthExceeded:
+		return fmt.Sprintf(`"%s" exceeds the maximum depth`, field)
+	case LengthMismatch:
+		return fmt.Sprintf(`expected "%s" to be %s but got %v elements`, field, TypeNameWithArticle(f.Expected), f.Value)
+	}
+
 	return fmt.Sprintf(
 		`expected "%s" to be %s but it's %s`,
-		FieldNameWithPath(f.Field, f.Path),
+		field,
 		TypeNameWithArticle(f.Expected),
 		TypeNameWithArticle(f.Actual),
 	)
@@ -101,16 +503,112 @@ func (f FieldMismatch) String() string {
 	return f.MessageWithField()
 }
 
+// Pointer returns the field name with its path as a JSON pointer.
+// e.g: "/Cat/Foo"
+func (f FieldMismatch) Pointer() string {
+	return JSONPointer(f.Field, f.Path)
+}
+
 // CompareOpts can be used to configure how CompareMapToStruct works.
 type CompareOpts struct {
 	// ConvertibleFunc is the function used to check if a value can be safely converted
 	// to a type.
 	ConvertibleFunc ConvertibleFunc
 
+	// ConvertibleReasonFunc is the same as ConvertibleFunc but can also return the
+	// reason for a mismatch. If set, it's used instead of ConvertibleFunc.
+	ConvertibleReasonFunc ConvertibleReasonFunc
+
 	// TypeNameFunc is the function used to convert a type into a string.
 	TypeNameFunc TypeNameFunc
+
+	// DisallowUnknownFields causes CompareResults.Errors to include fields which
+	// are in src but not in dst. Unknown fields are always added to UnknownFields
+	// regardless of this setting.
+	DisallowUnknownFields bool
+
+	// Required decides which fields are required, for fields which aren't tagged
+	// with schema:"required" or schema:"optional". By default only fields tagged
+	// as required are required.
+	Required RequiredPolicy
+
+	// PathStyle is how the paths of fields are written by the String methods of
+	// the results and by CompareResults.Errors. By default paths are dot separated.
+	PathStyle PathStyle
+
+	// TagName is the struct tag used for the names of fields, e.g. "yaml". Fields
+	// which aren't tagged with a name use the field name, and embedded structs are
+	// inlined if they're untagged or have the inline option. Defaults to "json".
+	TagName string
+
+	// FieldNameFunc is the function used to get the names of fields. If set, it's
+	// used instead of TagName. JSONFieldName, YAMLFieldName, BSONFieldName,
+	// MapstructureFieldName, SnakeCaseFieldName and CamelCaseFieldName can be used
+	// here. Structs compiled with a custom FieldNameFunc are not cached, so Compile
+	// should be used to reuse them.
+	FieldNameFunc FieldNameFunc
+
+	// CaseInsensitive matches keys in src to fields the same way as encoding/json:
+	// a key which is the same as the field name is preferred, otherwise a key
+	// which is the same ignoring case is used. Keys which only matched ignoring
+	// case, or which were ignored because another key matched the same field, are
+	// added to CompareResults.Warnings.
+	CaseInsensitive bool
+
+	// SortByPath sorts the results by the path of each field. By default, fields
+	// are reported in the order they are declared in the struct, with nested fields
+	// reported where their parent is declared, and map keys reported in sorted
+	// order.
+	SortByPath bool
+
+	// MaxDepth is the maximum number of objects and arrays that can be nested in src,
+	// including src itself. A value nested deeper than this is reported as a mismatch
+	// with the DepthExceeded reason, which stops a deeply nested (or cyclic) src from
+	// using too much memory or overflowing the stack. Defaults to DefaultMaxDepth.
+	MaxDepth int
+
+	// patch is set when comparing a JSON merge patch, see ComparePatchToStruct.
+	patch bool
+
+	// coerce is set when ConvertibleFunc is CoercingCanConvert, which means values
+	// are coerced to the dst type and reported in CompareResults.CoercedFields.
+	coerce bool
 }
 
+// DefaultMaxDepth is the default CompareOpts.MaxDepth. Each result has the full path
+// to its field, so the memory used grows with the square of the depth. This keeps it
+// small while still allowing any reasonable document.
+const DefaultMaxDepth = 128
+
+// RequiredPolicy is a set of flags which decide which fields are required.
+type RequiredPolicy uint8
+
+const (
+	// RequireAll makes every field required unless it's tagged schema:"optional".
+	RequireAll RequiredPolicy = 1 << iota
+
+	// OmitEmptyIsOptional makes fields with the json omitempty option optional.
+	// This only has an effect when combined with RequireAll.
+	OmitEmptyIsOptional
+
+	// PointerIsOptional makes pointer fields optional. This only has an effect when
+	// combined with RequireAll.
+	PointerIsOptional
+)
+
+// PathStyle is a way of writing the path of a field.
+type PathStyle uint8
+
+const (
+	// DotPath writes paths as dot separated field names, e.g. "address.city".
+	// CompareResults.Errors nests the errors using the same structure as src.
+	DotPath PathStyle = iota
+
+	// PointerPath writes paths as JSON pointers (RFC 6901), e.g. "/address/city".
+	// CompareResults.Errors is a flat map keyed by the pointer of each field.
+	PointerPath
+)
+
 // ConvertibleFunc takes a dst type (t) and a src value (v) and returns true if
 // v is convertible to t.
 type ConvertibleFunc func(t reflect.Type, v reflect.Value) bool
@@ -124,70 +622,95 @@ in dst, it checks if: the field is in src, and if the types are compatible. The
 of the field is the same as the JSON name.
 
 Fields that have a type mismatch are added to MismatchedFields in the returned
-CompareResults. Any fields in dst that are not in src are added to MissingFields.
+CompareResults. Any fields in dst that are not in src are added to MissingFields, and
+any fields in src that are not in dst are added to UnknownFields.
+
+Fields in dst which are required and not in src are also added to
+MissingRequiredFields, which is included in CompareResults.Errors. A field can be
+tagged with schema:"required" or schema:"optional", otherwise CompareOpts.Required
+decides if it's required.
+
+A field which is null in src is only allowed if the dst field is a pointer, a
+Nullable[T] or one of the sql.Null* types. This can be overridden by tagging the
+field with schema:"nullable" or schema:"notnull". Fields which were null are added
+to NullFields.
+
+Fields can also have validation rules in a schema tag, e.g. `schema:"min=1,max=120"`.
+Fields which have the correct type but violate a rule are added to InvalidFields.
+The supported rules are min, max, len, minlen, maxlen, minitems, maxitems, pattern
+and enum. Since a pattern may contain commas, it must be the last rule in the tag:
+
+	Code  string `schema:"len=2,pattern=^[A-Z]{2}$"`
+	Color string `schema:"enum=red|green|blue"`
 
 A type mismatch occurs if a value cannot be converted to a different type without
 modifying it, parsing it, etc.
 
 Examples of a type mismatch (src -> dst):
-    string -> int
-    int    -> string
-    bool   -> int
-    float  -> int
-    null   -> string
+
+	string -> int
+	int    -> string
+	bool   -> int
+	float  -> int
+	null   -> string
 
 Examples of allowed type conversions (src -> dst):
-    int  -> float
-    <T>  -> *<T>
-    null -> *<T>
 
-Embedded structs work as you might expect. The fields of the struct are treated as
-if they were hardcoded into dst. In other words, embedding does not change how
-src should be structured.
+	int  -> float
+	<T>  -> *<T>
+	null -> *<T>
+
+Embedded structs work the same way as encoding/json. The fields of the struct are
+treated as if they were hardcoded into dst, unless the embedded struct is tagged
+with a name. If more than one field has the same name, the shallowest field wins,
+followed by a tagged field, otherwise none of them are used. Unexported fields are
+ignored.
+
+Slices and arrays are checked element by element. The index of an element is used
+as its field name, so a mismatch in the "city" field of the third address would be
+reported as "addresses.2.city". Arrays must also have the same length as src.
+
+Maps are checked value by value, using the key as the field name. Integer keyed
+maps (e.g. map[int]string) also check that each key is a valid integer.
+
+The struct is only walked once per type, after which it's cached. Compile can be
+used to hold on to the compiled struct and options directly.
 */
 func CompareMapToStruct(dst interface{}, src map[string]interface{}, opts *CompareOpts) (*CompareResults, error) {
-	if opts == nil {
-		opts = &CompareOpts{
-			ConvertibleFunc: DefaultCanConvert,
-			TypeNameFunc:    DetailedTypeName,
-		}
-	} else {
-		// Create a copy so we can set defaults without modifying the call arg.
-		opts = &CompareOpts{
-			ConvertibleFunc: opts.ConvertibleFunc,
-			TypeNameFunc:    opts.TypeNameFunc,
-		}
+	s, err := Compile(dst, opts)
 
-		if opts.ConvertibleFunc == nil {
-			opts.ConvertibleFunc = DefaultCanConvert
-		}
-		if opts.TypeNameFunc == nil {
-			opts.TypeNameFunc = DetailedTypeName
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	v := reflect.ValueOf(dst)
+	return s.Compare(src)
+}
 
-	if !v.IsValid() || v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
-		return nil, ErrInvalidDst
-	} else if src == nil {
-		return nil, ErrNilSrc
-	}
+/*
+DecodeMapToStruct is the same as CompareMapToStruct, except the values in src are
+also decoded into dst while it's being compared. This avoids having to compare the
+map and then unmarshal it again.
+
+Fields in src which are missing or have a type mismatch are left untouched in dst,
+so dst may be partially populated if there are errors. The conversions are the same
+as the ones allowed by the ConvertibleFunc, e.g. int -> float, <T> -> *<T>, and
+null -> *<T> (which sets the pointer to nil).
+*/
+func DecodeMapToStruct(dst interface{}, src map[string]interface{}, opts *CompareOpts) (*CompareResults, error) {
+	s, err := Compile(dst, opts)
 
-	results := &CompareResults{
-		MismatchedFields: []FieldMismatch{},
-		MissingFields:    []FieldMissing{},
+	if err != nil {
+		return nil, err
 	}
 
-	compare(v.Elem().Type(), src, opts, results)
-
-	return results, nil
+	return s.Decode(dst, src)
 }
 
 // DefaultCanConvert returns whether value v is convertible to type t.
 //
 // If t is a pointer and v is not nil, it checks if v is convertible to the type that
-// t points to.
+// t points to. Numbers must also fit in the bit size of t, e.g. 300 can't be converted
+// to a uint8.
 func DefaultCanConvert(t reflect.Type, v reflect.Value) bool {
 	isPtr := t.Kind() == reflect.Ptr
 	isStruct := t.Kind() == reflect.Struct
@@ -206,13 +729,29 @@ func DefaultCanConvert(t reflect.Type, v reflect.Value) bool {
 
 	// If the dst is a struct, we should check its nested fields.
 	if isStruct {
-		return v.Kind() == reflect.Map
+		return isStringMap(v)
+	}
+
+	// If the dst is a slice, array or map, the elements are checked individually.
+	switch dstType.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			return true
+		}
+	case reflect.Map:
+		return isStringMap(v)
 	}
 
 	if !v.Type().ConvertibleTo(dstType) {
 		return false
 	}
 
+	// reflect converts an integer to a string as a rune, e.g. 65 -> "A", so only
+	// strings can be converted to a string.
+	if dstType.Kind() == reflect.String && v.Kind() != reflect.String {
+		return false
+	}
+
 	// Handle converting to an integer type.
 	if dstInt, unsigned := isIntegerType(dstType); dstInt {
 		if isFloatType(v.Type()) {
@@ -223,95 +762,600 @@ func DefaultCanConvert(t reflect.Type, v reflect.Value) bool {
 			} else if unsigned && f < 0 {
 				return false
 			}
-		} else if srcInt, _ := isIntegerType(v.Type()); srcInt {
+		} else if srcInt, srcUnsigned := isIntegerType(v.Type()); srcInt && !srcUnsigned {
 			if unsigned && v.Int() < 0 {
 				return false
 			}
 		}
 	}
 
-	return true
+	// The number must fit in the dst type without wrapping around.
+	return !overflows(dstType, v)
 }
 
 // compare performs the actual check between the map fields and the struct fields.
-func compare(t reflect.Type, src map[string]interface{}, opts *CompareOpts, results *CompareResults) {
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		fieldName, skip := parseField(f)
+// If dst is valid, the fields of src are also decoded into the struct dst.
+func compare(info *typeInfo, src map[string]interface{}, dst reflect.Value, path []string, opts *CompareOpts, results *CompareResults) {
+	var keys map[string]string
+
+	if opts.CaseInsensitive {
+		keys = foldKeys(info, src, path, results)
+	}
+
+	for i := range info.fields {
+		f := &info.fields[i]
+		key := f.name
+
+		if k, ok := keys[f.name]; ok {
+			key = k
+		}
+
+		srcField, ok := src[key]
+
+		// Fields which are missing from a patch are left as they are.
+		if !ok && opts.patch {
+			continue
+		} else if !ok {
+			missing := FieldMissing{Field: f.name, Path: path}
+			results.MissingFields = append(results.MissingFields, missing)
+
+			if f.isRequired(opts.Required) {
+				results.MissingRequiredFields = append(results.MissingRequiredFields, missing)
+			}
 
-		if skip {
 			continue
 		}
 
-		// If the field is embedded also check its fields.
-		if f.Anonymous {
-			compare(f.Type, src, opts, results)
+		var fieldDst reflect.Value
+
+		if dst.IsValid() {
+			fieldDst = fieldByIndex(dst, f.index)
+		}
+
+		compareField(f, reflect.ValueOf(srcField), fieldDst, path, opts, results)
+	}
+
+	var unknown []string
+
+	for key := range src {
+		if _, ok := info.fieldNames[key]; ok {
+			continue
+		} else if _, ok := info.foldedNames[foldName(key)]; ok && opts.CaseInsensitive {
 			continue
 		}
 
-		if srcField, ok := src[fieldName]; ok {
-			srcValue := reflect.ValueOf(srcField)
+		unknown = append(unknown, key)
+	}
 
-			if !opts.ConvertibleFunc(f.Type, srcValue) {
-				var srcTypeName string
+	sort.Strings(unknown)
 
-				if !srcValue.IsValid() {
-					srcTypeName = "null"
-				} else {
-					srcTypeName = opts.TypeNameFunc(srcValue.Type())
+	for _, key := range unknown {
+		results.UnknownFields = append(results.UnknownFields, FieldUnknown{Field: key, Path: path})
+	}
+}
+
+// fieldByIndex returns the nested field of struct v at index, allocating any nil
+// embedded struct pointers on the way. If a nil pointer can't be set because the
+// embedded struct is unexported, the zero Value is returned and the field is only
+// compared (encoding/json returns an error in this case).
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}
 				}
 
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// compareField compares the src value (v) to struct field f, decoding it into dst if
+// dst is valid. The field's rules are checked if the value has the correct type.
+func compareField(f *fieldInfo, v reflect.Value, dst reflect.Value, path []string, opts *CompareOpts, results *CompareResults) {
+	// The nullable and notnull tags take priority over the type when the value
+	// is null.
+	if !v.IsValid() && f.notNull {
+		addMismatch(unwrap(f.info).typ, v, NullNotAllowed, f.name, path, opts, results)
+		return
+	} else if !v.IsValid() && f.nullable {
+		setNull(f.info, dst)
+		results.NullFields = append(results.NullFields, FieldNull{Field: f.name, Path: path})
+
+		return
+	}
+
+	if compareValue(f.info, v, dst, f.name, path, opts, results) {
+		if !v.IsValid() {
+			results.NullFields = append(results.NullFields, FieldNull{Field: f.name, Path: path})
+		}
+
+		checkRules(f, v, path, results)
+	}
+}
+
+// compareValue checks if the src value (v) can be converted to type t. If t is a
+// struct, slice, array or map then the nested fields or elements are checked as well.
+// If dst is valid, v is also decoded into dst. Returns false if v itself can't be
+// converted, not counting any of its nested fields or elements.
+func compareValue(info *typeInfo, v reflect.Value, dst reflect.Value, fieldName string, path []string, opts *CompareOpts, results *CompareResults) bool {
+	t := info.typ
+
+	if info.wrapper != notWrapper {
+		return compareWrapper(info, v, dst, fieldName, path, opts, results)
+	}
+
+	// A pointer to a wrapper or a type which unmarshals itself is checked as the
+	// type it points to, since neither can be compared as a plain struct.
+	if v.IsValid() && t.Kind() == reflect.Ptr && (info.elem.wrapper != notWrapper || info.elem.unmarshaler) {
+		if dst.IsValid() {
+			if dst.IsNil() {
+				dst.Set(reflect.New(t.Elem()))
+			}
+
+			dst = dst.Elem()
+		}
+
+		return compareValue(info.elem, v, dst, fieldName, path, opts, results)
+	}
+
+	// Types which unmarshal themselves are checked by unmarshaling the value instead.
+	if v.IsValid() && info.unmarshaler {
+		return unmarshalValue(t, v, dst, fieldName, path, opts, results)
+	}
+
+	if ok, reason := opts.ConvertibleReasonFunc(t, v); !ok {
+		addMismatch(t, v, reason, fieldName, path, opts, results)
+		return false
+	}
+
+	// A nil value has nothing to check further.
+	if !v.IsValid() {
+		if dst.IsValid() {
+			dst.Set(reflect.Zero(t))
+		}
+
+		return true
+	}
+
+	// Objects and arrays are only checked up to the maximum depth. src is the first
+	// level, so this value is nested one level deeper than its path.
+	switch indirectType(t).Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		if len(path)+2 > opts.MaxDepth {
+			addMismatch(t, v, DepthExceeded, fieldName, path, opts, results)
+			return false
+		}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		info = info.elem
+		t = info.typ
+
+		if dst.IsValid() {
+			if dst.IsNil() {
+				dst.Set(reflect.New(t))
+			}
+
+			dst = dst.Elem()
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		// If the field is a nested struct also check its fields.
+		if nested, ok := stringMap(v); ok {
+			compare(info, nested, dst, appendPath(path, fieldName), opts, results)
+			return true
+		} else if v.Kind() == reflect.Map {
+			addMismatch(t, v, TypeMismatch, fieldName, path, opts, results)
+			return false
+		}
+
+	case reflect.Slice, reflect.Array:
+		// encoding/json decodes a string into a []byte as base64, so it's checked by
+		// unmarshaling it instead of converting the string's bytes.
+		if v.Kind() == reflect.String && t.Kind() == reflect.Slice {
+			return unmarshalValue(t, v, dst, fieldName, path, opts, results)
+		}
+
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			break
+		}
+
+		// Fixed size arrays must have the same number of elements.
+		if t.Kind() == reflect.Array && t.Len() != v.Len() {
+			mismatch := FieldMismatch{
+				Field:    fieldName,
+				Expected: opts.TypeNameFunc(t),
+				Actual:   opts.TypeNameFunc(v.Type()),
+				Path:     path,
+				Reason:   LengthMismatch,
+				Value:    v.Len(),
+			}
+
+			results.MismatchedFields = append(results.MismatchedFields, mismatch)
+
+			return false
+		}
+
+		if dst.IsValid() && t.Kind() == reflect.Slice {
+			dst.Set(reflect.MakeSlice(t, v.Len(), v.Len()))
+		}
+
+		elemPath := appendPath(path, fieldName)
+
+		// A patch replaces slices and arrays entirely, so the elements are complete
+		// values rather than patches.
+		if opts.patch {
+			copied := *opts
+			copied.patch = false
+			opts = &copied
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			var elemDst reflect.Value
+
+			if dst.IsValid() {
+				elemDst = dst.Index(i)
+			}
+
+			compareValue(info.elem, indirectInterface(v.Index(i)), elemDst, strconv.Itoa(i), elemPath, opts, results)
+		}
+
+		return true
+
+	case reflect.Map:
+		if v.Kind() != reflect.Map {
+			break
+		}
+
+		src, ok := stringMap(v)
+
+		// Only maps with string keys can be compared key by key.
+		if !ok {
+			addMismatch(t, v, TypeMismatch, fieldName, path, opts, results)
+			return false
+		}
+
+		if dst.IsValid() && dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(t, v.Len()))
+		}
+
+		elemPath := appendPath(path, fieldName)
+
+		for _, key := range sortedMapKeys(src) {
+			dstKey, ok := parseMapKey(t.Key(), key)
+
+			// Keys that can't be unmarshaled into the key type are reported using
+			// the key as the field name.
+			if !ok {
 				mismatch := FieldMismatch{
-					Field:    fieldName,
-					Expected: opts.TypeNameFunc(f.Type),
-					Actual:   srcTypeName,
+					Field:    key,
+					Expected: opts.TypeNameFunc(t.Key()),
+					Actual:   "string",
+					Path:     elemPath,
 				}
 
 				results.MismatchedFields = append(results.MismatchedFields, mismatch)
 
 				continue
 			}
-		} else {
-			missing := FieldMissing{Field: fieldName}
-			results.MissingFields = append(results.MissingFields, missing)
 
-			continue
+			var elemDst reflect.Value
+
+			val := reflect.ValueOf(src[key])
+
+			// A null value in a patch deletes the key.
+			if opts.patch && !val.IsValid() {
+				if dst.IsValid() {
+					dst.SetMapIndex(dstKey, reflect.Value{})
+				}
+
+				continue
+			}
+
+			if dst.IsValid() {
+				elemDst = reflect.New(t.Elem()).Elem()
+
+				// A patch is merged into the existing value.
+				if existing := dst.MapIndex(dstKey); opts.patch && existing.IsValid() {
+					elemDst.Set(existing)
+				}
+			}
+
+			compareValue(info.elem, val, elemDst, key, elemPath, opts, results)
+
+			if dst.IsValid() {
+				dst.SetMapIndex(dstKey, elemDst)
+			}
 		}
 
-		// If the field is a nested struct also check its fields.
-		if isStructType(f.Type) {
-			nested := src[fieldName].(map[string]interface{})
-			nestedType := f.Type
+		return true
+
+	case reflect.Interface:
+		// An object in a patch is merged into the existing value (RFC 7386), since
+		// there's no type to compare the patch's fields to.
+		if patch, ok := stringMap(v); ok && opts.patch {
+			var target interface{}
+
+			if dst.IsValid() {
+				target = dst.Interface()
+			}
+
+			// The object is nested one level deeper than its path, and the levels
+			// left are shared by the objects nested in it.
+			merged, ok := mergePatch(target, patch, opts.MaxDepth-len(path)-1)
+
+			if !ok {
+				addMismatch(t, v, DepthExceeded, fieldName, path, opts, results)
+				return false
+			}
 
-			if f.Type.Kind() == reflect.Ptr {
-				nestedType = nestedType.Elem()
+			if dst.IsValid() {
+				dst.Set(reflect.ValueOf(merged))
 			}
 
-			checkNestedFields(nestedType, fieldName, nested, opts, results)
+			return true
 		}
 	}
+
+	// Values which can be coerced are reported so the caller knows the value was
+	// changed, e.g. "26" -> 26. This only applies to CoercingCanConvert.
+	if opts.coerce {
+		if coerced, ok := coerceValue(t, v); ok {
+			results.CoercedFields = append(results.CoercedFields, FieldCoerced{
+				Field:    fieldName,
+				Expected: opts.TypeNameFunc(t),
+				Actual:   opts.TypeNameFunc(v.Type()),
+				Path:     path,
+			})
+
+			if dst.IsValid() {
+				dst.Set(coerced)
+			}
+
+			return true
+		}
+	}
+
+	if dst.IsValid() && !assignValue(dst, v) {
+		addMismatch(t, v, TypeMismatch, fieldName, path, opts, results)
+		return false
+	}
+
+	return true
+}
+
+// unmarshalValue checks the src value (v) by unmarshaling it into type t, which
+// implements json.Unmarshaler or encoding.TextUnmarshaler. If dst is valid, the
+// unmarshaled value is stored in dst. Returns false if v couldn't be unmarshaled.
+func unmarshalValue(t reflect.Type, v reflect.Value, dst reflect.Value, fieldName string, path []string, opts *CompareOpts, results *CompareResults) bool {
+	out := reflect.New(t)
+	b, err := json.Marshal(v.Interface())
+
+	if err == nil {
+		err = json.Unmarshal(b, out.Interface())
+	}
+
+	if err != nil {
+		// A value of the wrong JSON type (e.g. a number for a TextUnmarshaler) is
+		// a regular type mismatch. Newer versions of encoding/json also wrap invalid
+		// base64 for a []byte in an UnmarshalTypeError, which is a parse error.
+		typeErr := &json.UnmarshalTypeError{}
+
+		if errors.As(err, &typeErr) && !errors.As(err, new(base64.CorruptInputError)) {
+			addMismatch(t, v, TypeMismatch, fieldName, path, opts, results)
+			return false
+		}
+
+		results.MismatchedFields = append(results.MismatchedFields, FieldMismatch{
+			Field:    fieldName,
+			Expected: opts.TypeNameFunc(t),
+			Actual:   opts.TypeNameFunc(v.Type()),
+			Path:     path,
+			Reason:   ParseError,
+			Value:    v.Interface(),
+			Detail:   err.Error(),
+		})
+
+		return false
+	}
+
+	if dst.IsValid() {
+		dst.Set(out.Elem())
+	}
+
+	return true
+}
+
+// assignValue sets dst to the src value (v), converting it if needed. If v can't be
+// converted directly, it falls back to encoding/json. Returns false if v could not
+// be assigned to dst.
+func assignValue(dst reflect.Value, v reflect.Value) bool {
+	// Converting a string to a slice would copy its bytes (or runes) rather than
+	// decoding it the way encoding/json does, and converting an integer to a string
+	// would give a rune.
+	isStringToSlice := v.Kind() == reflect.String && dst.Kind() == reflect.Slice
+	isToString := v.Kind() != reflect.String && dst.Kind() == reflect.String
+
+	if !isStringToSlice && !isToString && v.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(v.Convert(dst.Type()))
+		return true
+	}
+
+	b, err := json.Marshal(v.Interface())
+
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(b, dst.Addr().Interface()) == nil
 }
 
-func checkNestedFields(t reflect.Type, fieldName string, src map[string]interface{}, opts *CompareOpts, results *CompareResults) {
-	// Remember count of fields to check if new errors occured.
-	mismatchCount := len(results.MismatchedFields)
-	missingCount := len(results.MissingFields)
+// parseMapKey parses the JSON object key into a map key of type t. String keys always
+// work, integer keys must be a valid integer that fits in the key type, and keys
+// which implement encoding.TextUnmarshaler must unmarshal without an error.
+func parseMapKey(t reflect.Type, key string) (reflect.Value, bool) {
+	// Keys which implement encoding.TextUnmarshaler are parsed the same way
+	// encoding/json does, which takes priority over the key's kind.
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		k := reflect.New(t)
+
+		if err := k.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+			return k.Elem(), false
+		}
+
+		return k.Elem(), true
+	}
+
+	k := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, t.Bits())
+
+		if err != nil {
+			return k, false
+		}
 
-	compare(t, src, opts, results)
+		k.SetInt(n)
 
-	// If there were new mismatched fields, add the current field name to their path.
-	if mismatchCount != len(results.MismatchedFields) {
-		for mi := mismatchCount; mi < len(results.MismatchedFields); mi++ {
-			results.MismatchedFields[mi].Path = append([]string{fieldName}, results.MismatchedFields[mi].Path...)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(key, 10, t.Bits())
+
+		if err != nil {
+			return k, false
 		}
+
+		k.SetUint(n)
+
+	case reflect.String:
+		k.SetString(key)
+
+	default:
+		if !reflect.TypeOf(key).ConvertibleTo(t) {
+			return k, false
+		}
+
+		k.Set(reflect.ValueOf(key).Convert(t))
+	}
+
+	return k, true
+}
+
+// sortedMapKeys returns the keys of map m in sorted order so that results are
+// reported consistently.
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
 	}
 
-	// If there were new missing fields, add the current field name to their path.
-	if missingCount != len(results.MissingFields) {
-		for mi := missingCount; mi < len(results.MissingFields); mi++ {
-			results.MissingFields[mi].Path = append([]string{fieldName}, results.MissingFields[mi].Path...)
+	sort.Strings(keys)
+
+	return keys
+}
+
+// isStringMap returns whether v is a map with string keys. This includes maps with a
+// named string type as the key, and maps with interface keys that are all strings,
+// such as the map[interface{}]interface{} that gopkg.in/yaml.v2 unmarshals into.
+func isStringMap(v reflect.Value) bool {
+	if v.Kind() != reflect.Map {
+		return false
+	}
+
+	switch v.Type().Key().Kind() {
+	case reflect.String:
+		return true
+	case reflect.Interface:
+		iter := v.MapRange()
+
+		for iter.Next() {
+			if iter.Key().Elem().Kind() != reflect.String {
+				return false
+			}
 		}
+
+		return true
+	}
+
+	return false
+}
+
+// stringMap returns v as a map[string]interface{} if it's a map with string keys (see
+// isStringMap). Other maps are copied so the rest of the walk only has to deal with
+// map[string]interface{}.
+func stringMap(v reflect.Value) (map[string]interface{}, bool) {
+	if v.Kind() != reflect.Map {
+		return nil, false
+	} else if m, ok := v.Interface().(map[string]interface{}); ok {
+		return m, true
+	} else if !isStringMap(v) {
+		return nil, false
+	}
+
+	m := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+
+	for iter.Next() {
+		m[indirectInterface(iter.Key()).String()] = iter.Value().Interface()
+	}
+
+	return m, true
+}
+
+// addMismatch adds a type mismatch between type t and the src value (v) to the results.
+func addMismatch(t reflect.Type, v reflect.Value, reason MismatchReason, fieldName string, path []string, opts *CompareOpts, results *CompareResults) {
+	var srcTypeName string
+
+	if !v.IsValid() {
+		srcTypeName = "null"
+	} else {
+		srcTypeName = opts.TypeNameFunc(v.Type())
+	}
+
+	mismatch := FieldMismatch{
+		Field:    fieldName,
+		Expected: opts.TypeNameFunc(t),
+		Actual:   srcTypeName,
+		Path:     path,
+		Reason:   reason,
+	}
+
+	if reason.hasValue() {
+		mismatch.Value = v.Interface()
 	}
+
+	results.MismatchedFields = append(results.MismatchedFields, mismatch)
+}
+
+// appendPath returns a copy of path with name added to the end. The copy ensures
+// that sibling fields never share the same backing array.
+func appendPath(path []string, name string) []string {
+	out := make([]string, len(path), len(path)+1)
+	copy(out, path)
+
+	return append(out, name)
+}
+
+// indirectInterface returns the value that v holds if v is an interface. A nil
+// interface returns the zero Value.
+func indirectInterface(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Interface {
+		return v.Elem()
+	}
+
+	return v
 }
 
 // isFloatType returns true if the type is a floating point. Note that this doesn't
@@ -335,35 +1379,3 @@ func isIntegerType(t reflect.Type) (yes bool, unsigned bool) {
 	}
 	return
 }
-
-// isStructType returns whether the type is a struct or a pointer to it.
-func isStructType(t reflect.Type) (yes bool) {
-	switch t.Kind() {
-	case reflect.Struct:
-		yes = true
-	case reflect.Ptr:
-		yes = t.Elem().Kind() == reflect.Struct
-	}
-	return
-}
-
-// parseField returns the field's JSON name.
-func parseField(f reflect.StructField) (name string, ignore bool) {
-	tag := f.Tag.Get("json")
-
-	if tag == "" {
-		return f.Name, false
-	} else if tag == "-" {
-		return "", true
-	}
-
-	if i := strings.Index(tag, ","); i != -1 {
-		if i == 0 {
-			return f.Name, false
-		} else {
-			return tag[:i], false
-		}
-	}
-
-	return tag, false
-}
@@ -2,8 +2,12 @@ package schema_test
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
 	"reflect"
 	"testing"
+	"time"
 
 	schema "github.com/Kangaroux/go-map-schema"
 	"github.com/stretchr/testify/require"
@@ -11,6 +15,7 @@ import (
 
 type mismatch schema.FieldMismatch
 type missing schema.FieldMissing
+type unknown schema.FieldUnknown
 
 type TestStruct struct {
 	Foo string
@@ -49,6 +54,58 @@ type TestStructNested struct {
 	}
 }
 
+type TestStructSlice struct {
+	Tags      []string
+	Addresses []struct {
+		City string
+	}
+	Ptrs  []*int
+	Grid  [][]int
+	Point [2]float64
+}
+
+type TestStructMap struct {
+	Scores map[string]int
+	Labels map[string]*struct {
+		City string
+	}
+	ByID map[int]string
+}
+
+type TestStructSized struct {
+	Uint8   uint8
+	Int8    int8
+	Int32   int32
+	Int64   int64
+	Uint64  uint64
+	Float32 float32
+	Ptr     *uint16
+}
+
+// Level is an int which is unmarshaled from a string.
+type Level int
+
+func (l *Level) UnmarshalJSON(b []byte) error {
+	switch string(b) {
+	case `"debug"`:
+		*l = 0
+	case `"info"`:
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %s", b)
+	}
+
+	return nil
+}
+
+type TestStructUnmarshaler struct {
+	Created time.Time
+	Updated *time.Time
+	IP      net.IP
+	Level   Level
+	ByIP    map[netip.Addr]int
+}
+
 func toJson(val interface{}) string {
 	out, err := json.Marshal(val)
 
@@ -164,6 +221,7 @@ func TestCompareMapToStruct_MismatchedFieldsSimple(t *testing.T) {
 					Field:    "Foo",
 					Expected: "string",
 					Actual:   "null",
+					Reason:   schema.NullNotAllowed,
 				},
 			},
 		},
@@ -194,6 +252,8 @@ func TestCompareMapToStruct_MismatchedFieldsSimple(t *testing.T) {
 					Field:    "Bar",
 					Expected: "int",
 					Actual:   "float64",
+					Reason:   schema.Truncation,
+					Value:    1.23,
 				},
 			},
 		},
@@ -246,6 +306,7 @@ func TestCompareMapToStruct_MismatchedFieldsEmbedded(t *testing.T) {
 					Field:    "Foo",
 					Expected: "string",
 					Actual:   "null",
+					Reason:   schema.NullNotAllowed,
 				},
 			},
 		},
@@ -418,6 +479,8 @@ func TestCompareMapToStruct_MismatchedFieldsUnsigned(t *testing.T) {
 					Field:    "Foo",
 					Expected: "uint",
 					Actual:   "float64",
+					Reason:   schema.Negative,
+					Value:    -1,
 				},
 			},
 		},
@@ -428,6 +491,8 @@ func TestCompareMapToStruct_MismatchedFieldsUnsigned(t *testing.T) {
 					Field:    "Foo",
 					Expected: "uint",
 					Actual:   "float64",
+					Reason:   schema.Truncation,
+					Value:    1.5,
 				},
 			},
 		},
@@ -713,6 +778,40 @@ func TestCompareResults_Errors(t *testing.T) {
 	}
 }
 
+// Tests that the PointerPath style writes paths as JSON pointers.
+func TestCompareResults_PointerPath(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Cat":{"Cat":{"A":{"Baz":true}}},"Addresses":[{"City":1}],"Map":{"a.b/c":1}}`), &src)
+
+	type S struct {
+		Cat       TestStructNested `json:"Cat"`
+		Addresses []struct {
+			City string
+		}
+		Map     map[string]string
+		Missing string `schema:"required"`
+	}
+
+	r, _ := schema.CompareMapToStruct(&S{}, src, &schema.CompareOpts{PathStyle: schema.PointerPath})
+
+	require.Equal(t, schema.MismatchError(map[string]interface{}{
+		"/Cat/Cat/A/Baz":    `expected a string but it's a bool`,
+		"/Addresses/0/City": `expected a string but it's a float64`,
+		"/Map/a.b~1c":       `expected a string but it's a float64`,
+		"/Missing":          `this field is required`,
+	}), r.Errors())
+
+	require.Equal(t, `expected "/Addresses/0/City" to be a string but it's a float64`, r.MismatchedFields[1].String())
+	require.Equal(t, "/Missing", r.MissingRequiredFields[0].String())
+
+	// Pointer always returns a JSON pointer, and String defaults to dots.
+	r, _ = schema.CompareMapToStruct(&S{}, src, nil)
+
+	require.Equal(t, "/Map/a.b~1c", r.MismatchedFields[2].Pointer())
+	require.Equal(t, `expected "Map.a.b/c" to be a string but it's a float64`, r.MismatchedFields[2].String())
+	require.Equal(t, "/Missing", r.MissingFields[len(r.MissingFields)-1].Pointer())
+}
+
 // Tests that Errors returns nil when there are no type mismatches.
 func TestCompareResults_ErrorsReturnsNil(t *testing.T) {
 	tests := []struct {
@@ -739,3 +838,466 @@ func TestCompareResults_ErrorsReturnsNil(t *testing.T) {
 		require.Nil(t, r.Errors())
 	}
 }
+
+// Tests that CompareMapToStruct checks each element of a slice or array against the
+// element type, and includes the index in the path.
+func TestCompareMapToStruct_MismatchedFieldsSlice(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		expected []mismatch
+	}{
+		{
+			srcJson:  `{}`,
+			expected: []mismatch{},
+		},
+		{
+			srcJson:  `{"Tags":[], "Addresses":[{"City":"a"}], "Ptrs":[1, null], "Grid":[[1, 2], []], "Point":[1, 2.5]}`,
+			expected: []mismatch{},
+		},
+		{
+			srcJson: `{"Tags":"a"}`,
+			expected: []mismatch{
+				{
+					Field:    "Tags",
+					Expected: "[]string",
+					Actual:   "string",
+				},
+			},
+		},
+		{
+			srcJson: `{"Tags":["a", 3, null]}`,
+			expected: []mismatch{
+				{
+					Field:    "1",
+					Expected: "string",
+					Actual:   "float64",
+					Path:     []string{"Tags"},
+				},
+				{
+					Field:    "2",
+					Expected: "string",
+					Actual:   "null",
+					Reason:   schema.NullNotAllowed,
+					Path:     []string{"Tags"},
+				},
+			},
+		},
+		{
+			srcJson: `{"Addresses":[{"City":"a"}, {"City":"b"}, {"City":true}, 5]}`,
+			expected: []mismatch{
+				{
+					Field:    "City",
+					Expected: "string",
+					Actual:   "bool",
+					Path:     []string{"Addresses", "2"},
+				},
+				{
+					Field:    "3",
+					Expected: "struct { City string }",
+					Actual:   "float64",
+					Path:     []string{"Addresses"},
+				},
+			},
+		},
+		{
+			srcJson: `{"Ptrs":[1.5], "Grid":[[1], [2, "3"]]}`,
+			expected: []mismatch{
+				{
+					Field:    "0",
+					Expected: "*int",
+					Actual:   "float64",
+					Reason:   schema.Truncation,
+					Value:    1.5,
+					Path:     []string{"Ptrs"},
+				},
+				{
+					Field:    "1",
+					Expected: "int",
+					Actual:   "string",
+					Path:     []string{"Grid", "1"},
+				},
+			},
+		},
+		{
+			srcJson: `{"Point":[1, 2, 3]}`,
+			expected: []mismatch{
+				{
+					Field:    "Point",
+					Expected: "[2]float64",
+					Actual:   "[]interface {}",
+					Reason:   schema.LengthMismatch,
+					Value:    3,
+				},
+			},
+		},
+		{
+			srcJson: `{"Point":[1, true]}`,
+			expected: []mismatch{
+				{
+					Field:    "1",
+					Expected: "float64",
+					Actual:   "bool",
+					Path:     []string{"Point"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(&TestStructSlice{}, src, nil)
+		require.JSONEq(t, toJson(test.expected), toJson(r.MismatchedFields), test.srcJson)
+	}
+}
+
+// Tests that missing fields of structs inside a slice include the index in the path.
+func TestCompareMapToStruct_MissingFieldsSlice(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Tags":[], "Addresses":[{"City":"a"}, {}], "Ptrs":[], "Grid":[], "Point":[0, 0]}`), &src)
+
+	r, _ := schema.CompareMapToStruct(&TestStructSlice{}, src, nil)
+	require.JSONEq(t, toJson([]missing{{Field: "City", Path: []string{"Addresses", "1"}}}), toJson(r.MissingFields))
+	require.Equal(t, "Addresses.1.City", r.MissingFields[0].String())
+}
+
+// Tests that CompareMapToStruct checks each value of a map against the value type,
+// and includes the key in the path.
+func TestCompareMapToStruct_MismatchedFieldsMap(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		expected []mismatch
+	}{
+		{
+			srcJson:  `{}`,
+			expected: []mismatch{},
+		},
+		{
+			srcJson:  `{"Scores":{"a":1, "b":2}, "Labels":{"home":{"City":"a"}, "work":null}, "ByID":{"12":"a", "-3":"b"}}`,
+			expected: []mismatch{},
+		},
+		{
+			srcJson: `{"Scores":[1, 2]}`,
+			expected: []mismatch{
+				{
+					Field:    "Scores",
+					Expected: "map[string]int",
+					Actual:   "[]interface {}",
+				},
+			},
+		},
+		{
+			srcJson: `{"Scores":{"b":"x", "a":1.5}}`,
+			expected: []mismatch{
+				{
+					Field:    "a",
+					Expected: "int",
+					Actual:   "float64",
+					Reason:   schema.Truncation,
+					Value:    1.5,
+					Path:     []string{"Scores"},
+				},
+				{
+					Field:    "b",
+					Expected: "int",
+					Actual:   "string",
+					Path:     []string{"Scores"},
+				},
+			},
+		},
+		{
+			srcJson: `{"Labels":{"home":{"City":3}, "work":true}}`,
+			expected: []mismatch{
+				{
+					Field:    "City",
+					Expected: "string",
+					Actual:   "float64",
+					Path:     []string{"Labels", "home"},
+				},
+				{
+					Field:    "work",
+					Expected: "*struct { City string }",
+					Actual:   "bool",
+					Path:     []string{"Labels"},
+				},
+			},
+		},
+		{
+			srcJson: `{"ByID":{"12":"a", "x":"b", "1.5":"c", "7":3}}`,
+			expected: []mismatch{
+				{
+					Field:    "1.5",
+					Expected: "int",
+					Actual:   "string",
+					Path:     []string{"ByID"},
+				},
+				{
+					Field:    "7",
+					Expected: "string",
+					Actual:   "float64",
+					Path:     []string{"ByID"},
+				},
+				{
+					Field:    "x",
+					Expected: "int",
+					Actual:   "string",
+					Path:     []string{"ByID"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(&TestStructMap{}, src, nil)
+		require.JSONEq(t, toJson(test.expected), toJson(r.MismatchedFields), test.srcJson)
+	}
+}
+
+// Tests that CompareMapToStruct identifies and returns a list of fields that are in
+// src but not dst, including inside embedded and nested structs.
+func TestCompareMapToStruct_UnknownFields(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		dst      interface{}
+		expected []unknown
+	}{
+		{
+			srcJson:  `{}`,
+			dst:      &TestStruct{},
+			expected: []unknown{},
+		},
+		{
+			srcJson:  `{"Foo":"","Bar":0,"Baz":3.14}`,
+			dst:      &TestStruct{},
+			expected: []unknown{},
+		},
+		{
+			srcJson:  `{"Foo":"","foo":"","Qux":1}`,
+			dst:      &TestStruct{},
+			expected: []unknown{{Field: "Qux"}, {Field: "foo"}},
+		},
+		{
+			srcJson:  `{"Foo":"","Butt":true,"Other":1}`,
+			dst:      &TestStructEmbedded{},
+			expected: []unknown{{Field: "Other"}},
+		},
+		{
+			srcJson:  `{"a":"","IgnoreMe":"","LowercaseA":""}`,
+			dst:      &TestStructTags{},
+			expected: []unknown{{Field: "IgnoreMe"}, {Field: "LowercaseA"}},
+		},
+		{
+			srcJson: `{"User":{"Foo":"","X":1}, "Cat":{"A":{"Baz":"","Y":2}, "Z":3}}`,
+			dst:     &TestStructNested{},
+			expected: []unknown{
+				{Field: "X", Path: []string{"User"}},
+				{Field: "Y", Path: []string{"Cat", "A"}},
+				{Field: "Z", Path: []string{"Cat"}},
+			},
+		},
+		{
+			srcJson:  `{"Addresses":[{"City":""}, {"City":"","Zip":1}]}`,
+			dst:      &TestStructSlice{},
+			expected: []unknown{{Field: "Zip", Path: []string{"Addresses", "1"}}},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(test.dst, src, nil)
+		require.JSONEq(t, toJson(test.expected), toJson(r.UnknownFields), test.srcJson)
+	}
+}
+
+// Tests that Errors only includes unknown fields if DisallowUnknownFields is set.
+func TestCompareResults_ErrorsUnknownFields(t *testing.T) {
+	src := make(map[string]interface{})
+	json.Unmarshal([]byte(`{"Foo":"","Qux":1,"User":{"Bar":true}}`), &src)
+
+	r, _ := schema.CompareMapToStruct(&TestStruct{}, src, nil)
+	require.Nil(t, r.Errors())
+
+	r, _ = schema.CompareMapToStruct(&TestStructNested{}, src, &schema.CompareOpts{DisallowUnknownFields: true})
+	require.Equal(t, schema.MismatchError(map[string]interface{}{
+		"Foo": "unknown field",
+		"Qux": "unknown field",
+		"User": map[string]interface{}{
+			"Bar": "expected an int but it's a bool",
+		},
+	}), r.Errors())
+}
+
+// Tests that CompareMapToStruct identifies numbers which don't fit in the dst type
+// and reports them as an overflow.
+func TestCompareMapToStruct_MismatchedFieldsOverflow(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		expected []mismatch
+	}{
+		{
+			srcJson:  `{"Uint8":255,"Int8":-128,"Int32":2147483647,"Int64":-9223372036854775808,"Uint64":18446744073709550000,"Float32":3.4e38,"Ptr":65535}`,
+			expected: []mismatch{},
+		},
+		{
+			srcJson: `{"Uint8":300,"Int8":-129,"Int32":1e20,"Int64":9223372036854775808,"Uint64":1.8446744073709552e19,"Float32":1e39,"Ptr":65536}`,
+			expected: []mismatch{
+				{Field: "Uint8", Expected: "uint8", Actual: "float64", Reason: schema.Overflow, Value: 300},
+				{Field: "Int8", Expected: "int8", Actual: "float64", Reason: schema.Overflow, Value: -129},
+				{Field: "Int32", Expected: "int32", Actual: "float64", Reason: schema.Overflow, Value: 1e20},
+				{Field: "Int64", Expected: "int64", Actual: "float64", Reason: schema.Overflow, Value: 9223372036854775808.0},
+				{Field: "Uint64", Expected: "uint64", Actual: "float64", Reason: schema.Overflow, Value: 1.8446744073709552e19},
+				{Field: "Float32", Expected: "float32", Actual: "float64", Reason: schema.Overflow, Value: 1e39},
+				{Field: "Ptr", Expected: "*uint16", Actual: "float64", Reason: schema.Overflow, Value: 65536},
+			},
+		},
+		{
+			srcJson: `{"Uint8":-1,"Int8":1.5}`,
+			expected: []mismatch{
+				{Field: "Uint8", Expected: "uint8", Actual: "float64", Reason: schema.Negative, Value: -1},
+				{Field: "Int8", Expected: "int8", Actual: "float64", Reason: schema.Truncation, Value: 1.5},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(&TestStructSized{}, src, nil)
+		require.JSONEq(t, toJson(test.expected), toJson(r.MismatchedFields), test.srcJson)
+	}
+}
+
+// Tests that integer src values (not from JSON) are also checked for overflow.
+func TestCompareMapToStruct_MismatchedFieldsOverflowInt(t *testing.T) {
+	src := map[string]interface{}{"Uint8": 300, "Int8": int64(-5), "Int32": uint(1 << 40), "Uint64": uint8(3)}
+
+	r, _ := schema.CompareMapToStruct(&TestStructSized{}, src, nil)
+
+	expected := []mismatch{
+		{Field: "Uint8", Expected: "uint8", Actual: "int", Reason: schema.Overflow, Value: 300},
+		{Field: "Int32", Expected: "int32", Actual: "uint", Reason: schema.Overflow, Value: 1 << 40},
+	}
+
+	require.JSONEq(t, toJson(expected), toJson(r.MismatchedFields))
+}
+
+// Tests that overflow mismatches have their own message.
+func TestFieldMismatch_MessageOverflow(t *testing.T) {
+	src := map[string]interface{}{"Uint8": 300.0}
+
+	r, _ := schema.CompareMapToStruct(&TestStructSized{}, src, nil)
+
+	require.Equal(t, "value 300 overflows uint8", r.MismatchedFields[0].Message())
+	require.Equal(t, `value 300 of "Uint8" overflows uint8`, r.MismatchedFields[0].String())
+	require.Equal(t, schema.MismatchError{"Uint8": "value 300 overflows uint8"}, r.Errors())
+}
+
+// Tests that types which implement json.Unmarshaler or encoding.TextUnmarshaler are
+// checked by unmarshaling the src value.
+func TestCompareMapToStruct_MismatchedFieldsUnmarshaler(t *testing.T) {
+	tests := []struct {
+		srcJson  string
+		expected []mismatch
+	}{
+		{
+			srcJson:  `{"Created":"2021-06-30T12:00:00Z","Updated":null,"IP":"10.0.0.1","Level":"info","ByIP":{"::1":1}}`,
+			expected: []mismatch{},
+		},
+		{
+			srcJson:  `{"Updated":"2021-06-30T12:00:00Z"}`,
+			expected: []mismatch{},
+		},
+		{
+			srcJson: `{"Created":"yesterday","IP":"nope","Level":"loud"}`,
+			expected: []mismatch{
+				{
+					Field:    "Created",
+					Expected: "Time",
+					Actual:   "string",
+					Reason:   schema.ParseError,
+					Value:    "yesterday",
+					Detail:   (&time.Time{}).UnmarshalJSON([]byte(`"yesterday"`)).Error(),
+				},
+				{
+					Field:    "IP",
+					Expected: "IP",
+					Actual:   "string",
+					Reason:   schema.ParseError,
+					Value:    "nope",
+					Detail:   "invalid IP address: nope",
+				},
+				{
+					Field:    "Level",
+					Expected: "Level",
+					Actual:   "string",
+					Reason:   schema.ParseError,
+					Value:    "loud",
+					Detail:   `unknown level "loud"`,
+				},
+			},
+		},
+		{
+			srcJson: `{"Created":null,"Updated":{"a":1},"IP":12}`,
+			expected: []mismatch{
+				{
+					Field:    "Created",
+					Expected: "Time",
+					Actual:   "null",
+					Reason:   schema.NullNotAllowed,
+				},
+				{
+					Field:    "Updated",
+					Expected: "Time",
+					Actual:   "map[string]interface {}",
+				},
+				{
+					Field:    "IP",
+					Expected: "IP",
+					Actual:   "float64",
+				},
+			},
+		},
+		{
+			srcJson: `{"ByIP":{"::1":1,"x":2}}`,
+			expected: []mismatch{
+				{
+					Field:    "x",
+					Expected: "Addr",
+					Actual:   "string",
+					Path:     []string{"ByIP"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		// Unmarshal the json into a map.
+		src := make(map[string]interface{})
+		json.Unmarshal([]byte(test.srcJson), &src)
+
+		r, _ := schema.CompareMapToStruct(&TestStructUnmarshaler{}, src, nil)
+		require.JSONEq(t, toJson(test.expected), toJson(r.MismatchedFields), test.srcJson)
+		require.Empty(t, r.UnknownFields, test.srcJson)
+	}
+}
+
+// Tests that parse errors include the error in the message.
+func TestFieldMismatch_MessageParseError(t *testing.T) {
+	src := map[string]interface{}{"IP": "nope"}
+
+	r, _ := schema.CompareMapToStruct(&TestStructUnmarshaler{}, src, nil)
+
+	require.Equal(t, "not a valid IP: invalid IP address: nope", r.MismatchedFields[0].Message())
+	require.Equal(t, `"IP" is not a valid IP: invalid IP address: nope`, r.MismatchedFields[0].String())
+}
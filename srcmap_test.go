@@ -0,0 +1,115 @@
+package schema_test
+
+import (
+	"testing"
+
+	schema "github.com/Kangaroux/go-map-schema"
+	"github.com/stretchr/testify/require"
+)
+
+type srcMapKey string
+
+type TestStructSrcMapAddress struct {
+	City string
+	Zip  int
+}
+
+type TestStructSrcMap struct {
+	Name    string
+	Address TestStructSrcMapAddress
+	Tags    map[string]string
+	Items   []TestStructSrcMapAddress
+}
+
+// Tests that src can contain maps with string keys other than map[string]interface{}.
+func TestDecodeMapToStruct_StringKeyedMaps(t *testing.T) {
+	tests := []struct {
+		src      map[string]interface{}
+		expected TestStructSrcMap
+	}{
+		{
+			src: map[string]interface{}{
+				"Address": map[string]string{"City": "Paris"},
+				"Tags":    map[string]string{"a": "b"},
+			},
+			expected: TestStructSrcMap{
+				Address: TestStructSrcMapAddress{City: "Paris"},
+				Tags:    map[string]string{"a": "b"},
+			},
+		},
+		{
+			src: map[string]interface{}{
+				"Address": map[srcMapKey]interface{}{"City": "Paris", "Zip": 75001},
+			},
+			expected: TestStructSrcMap{
+				Address: TestStructSrcMapAddress{City: "Paris", Zip: 75001},
+			},
+		},
+		{
+			src: map[string]interface{}{
+				"Name":    "Jo",
+				"Address": map[interface{}]interface{}{"City": "Paris", "Zip": 75001},
+				"Tags":    map[interface{}]interface{}{"a": "b"},
+				"Items": []interface{}{
+					map[interface{}]interface{}{"City": "Rome"},
+				},
+			},
+			expected: TestStructSrcMap{
+				Name:    "Jo",
+				Address: TestStructSrcMapAddress{City: "Paris", Zip: 75001},
+				Tags:    map[string]string{"a": "b"},
+				Items:   []TestStructSrcMapAddress{{City: "Rome"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		actual := TestStructSrcMap{}
+
+		r, err := schema.DecodeMapToStruct(&actual, test.src, nil)
+		require.NoError(t, err)
+		require.Empty(t, r.MismatchedFields)
+		require.Equal(t, test.expected, actual)
+	}
+}
+
+// Tests that maps without string keys are reported as mismatches.
+func TestCompareMapToStruct_NonStringKeyedMaps(t *testing.T) {
+	tests := []struct {
+		src      map[string]interface{}
+		expected []mismatch
+	}{
+		{
+			src: map[string]interface{}{
+				"Address": map[int]string{1: "Paris"},
+			},
+			expected: []mismatch{
+				{Field: "Address", Expected: "TestStructSrcMapAddress", Actual: "map[int]string"},
+			},
+		},
+		{
+			src: map[string]interface{}{
+				"Address": map[interface{}]interface{}{"City": "Paris", 1: "a"},
+				"Tags":    map[interface{}]interface{}{true: "b"},
+			},
+			expected: []mismatch{
+				{Field: "Address", Expected: "TestStructSrcMapAddress", Actual: "map[interface {}]interface {}"},
+				{Field: "Tags", Expected: "map[string]string", Actual: "map[interface {}]interface {}"},
+			},
+		},
+		{
+			src: map[string]interface{}{
+				"Items": []interface{}{map[float64]interface{}{1: "a"}},
+			},
+			expected: []mismatch{
+				{Field: "0", Expected: "TestStructSrcMapAddress", Actual: "map[float64]interface {}", Path: []string{"Items"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		r, err := schema.CompareMapToStruct(&TestStructSrcMap{}, test.src, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, toJson(test.expected), toJson(r.MismatchedFields))
+	}
+}